@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		header     string
+		wantStatus int
+		wantTenant string
+	}{
+		{
+			name:       "tenant from principal",
+			principal:  &auth.Principal{Subject: "key-1", TenantID: "tenant-a"},
+			wantStatus: http.StatusOK,
+			wantTenant: "tenant-a",
+		},
+		{
+			name:       "cross-tenant header ignored",
+			principal:  &auth.Principal{Subject: "key-1", TenantID: "tenant-a"},
+			header:     "tenant-b",
+			wantStatus: http.StatusOK,
+			wantTenant: "tenant-a",
+		},
+		{
+			name:       "no principal",
+			header:     "tenant-b",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "principal without tenant",
+			principal:  &auth.Principal{Subject: "key-1"},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTenant string
+			handler := TenantMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotTenant = config.TenantFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/apis/v1/jobs", nil)
+			if tt.header != "" {
+				r.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.principal != nil {
+				r = r.WithContext(auth.WithPrincipal(r.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", gotTenant, tt.wantTenant)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const migrateUsage = "usage: api [flags] migrate up | down [N] | status | baseline [VERSION]"
+
+// runMigrate implements `api migrate`. Down reverts one migration unless a
+// count is given; baseline records version 1 unless a version is given.
+func runMigrate(ctx context.Context, pool *pgxpool.Pool, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, pool)
+		for _, version := range applied {
+			fmt.Printf("applied %04d\n", version)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("down: invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(ctx, pool, steps)
+		for _, version := range reverted {
+			fmt.Printf("reverted %04d\n", version)
+		}
+		return err
+	case "baseline":
+		version := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("baseline: invalid version %q", args[1])
+			}
+			version = n
+		}
+		recorded, err := migrations.Baseline(ctx, pool, version)
+		for _, v := range recorded {
+			fmt.Printf("recorded %04d\n", v)
+		}
+		return err
+	case "status":
+		all, err := migrations.All()
+		if err != nil {
+			return err
+		}
+		applied, err := migrations.Applied(ctx, pool)
+		if err != nil {
+			return err
+		}
+		appliedAt := map[int]string{}
+		for _, migration := range applied {
+			appliedAt[migration.Version] = migration.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		for _, migration := range all {
+			state, ok := appliedAt[migration.Version]
+			if !ok {
+				state = "pending"
+			}
+			fmt.Printf("%04d  %-30s  %s\n", migration.Version, migration.Name, state)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runReencrypt implements `api reencrypt`. It moves every encrypted payload
+// onto the primary master key so retired keys can be dropped from the
+// keyring, and with -include-plaintext also encrypts payloads written before
+// encryption was enabled.
+func runReencrypt(ctx context.Context, pool *pgxpool.Pool, keyring *envelope.Keyring, args []string) error {
+	fs := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	batch := fs.Int("batch", 500, "jobs per batch")
+	includePlaintext := fs.Bool("include-plaintext", false, "also encrypt plaintext payloads")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if keyring == nil {
+		return errors.New("no master keys configured; set master_key_file or master_keys")
+	}
+	if *batch < 1 {
+		return fmt.Errorf("invalid batch size %d", *batch)
+	}
+
+	var total jobops.ReencryptResult
+	for {
+		result, err := jobops.Reencrypt(ctx, pool, keyring, total.LastID, *batch, *includePlaintext)
+		total.Rewrapped += result.Rewrapped
+		total.Encrypted += result.Encrypted
+		if err != nil {
+			return fmt.Errorf("after job %d: %w", result.LastID, err)
+		}
+		if result.LastID == total.LastID {
+			break
+		}
+		total.LastID = result.LastID
+	}
+	fmt.Printf("rewrapped %d, encrypted %d onto key %s\n", total.Rewrapped, total.Encrypted, keyring.PrimaryID())
+	return nil
+}
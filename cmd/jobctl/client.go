@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	profile    Profile
+	httpClient *http.Client
+}
+
+func NewClient(profile Profile) *Client {
+	return &Client{profile: profile, httpClient: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// APIError is a non-2xx response from the API.
+type APIError struct {
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), strings.TrimSpace(e.Body))
+}
+
+// Do sends body as JSON to path under /apis/v1 and decodes the response
+// into out when out is non-nil.
+func (c *Client) Do(method, path string, query url.Values, body, out interface{}) error {
+	target := strings.TrimRight(c.profile.Endpoint, "/") + "/apis/v1" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.profile.APIKey != "":
+		req.Header.Set("X-API-Key", c.profile.APIKey)
+	case c.profile.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.profile.Token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return &APIError{Status: resp.StatusCode, Body: string(raw)}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DEFAULT_ENDPOINT = "http://localhost:8000"
+	CONFIG_ENV       = "JOBCTL_CONFIG"
+)
+
+// Profile is one API target. A profile authenticates with either an API key
+// or a bearer token.
+type Profile struct {
+	Endpoint string `yaml:"endpoint"`
+	APIKey   string `yaml:"api_key"`
+	Token    string `yaml:"token"`
+}
+
+// CLIConfig is the jobctl config file, by default
+// ~/.config/jobctl/config.yaml:
+//
+//	current_profile: prod
+//	profiles:
+//	  prod:
+//	    endpoint: https://jobs.example.com
+//	    api_key: jq_...
+type CLIConfig struct {
+	CurrentProfile string             `yaml:"current_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv(CONFIG_ENV); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jobctl", "config.yaml"), nil
+}
+
+// resolveProfile picks the named profile (or the file's current profile) and
+// lets JOBCTL_ENDPOINT, JOBCTL_API_KEY, JOBCTL_TOKEN and the global flags
+// override it. A missing config file is not an error.
+func resolveProfile(name string, overrides Profile) (Profile, error) {
+	var cfg CLIConfig
+	path, err := configPath()
+	if err != nil {
+		return Profile{}, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Profile{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(raw, &cfg); err != nil {
+			return Profile{}, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	if name == "" {
+		name = cfg.CurrentProfile
+	}
+	profile, ok := cfg.Profiles[name]
+	if name != "" && !ok {
+		return Profile{}, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+
+	for _, override := range []Profile{
+		{Endpoint: os.Getenv("JOBCTL_ENDPOINT"), APIKey: os.Getenv("JOBCTL_API_KEY"), Token: os.Getenv("JOBCTL_TOKEN")},
+		overrides,
+	} {
+		if override.Endpoint != "" {
+			profile.Endpoint = override.Endpoint
+		}
+		if override.APIKey != "" {
+			profile.APIKey, profile.Token = override.APIKey, ""
+		}
+		if override.Token != "" {
+			profile.Token, profile.APIKey = override.Token, ""
+		}
+	}
+	if profile.Endpoint == "" {
+		profile.Endpoint = DEFAULT_ENDPOINT
+	}
+	return profile, nil
+}
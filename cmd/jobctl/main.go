@@ -0,0 +1,357 @@
+// Command jobctl manages jobs through the job queue API.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+const usage = `usage: jobctl [global flags] <command> [flags] [args]
+
+Commands:
+  submit      submit a job from flags or a JSON file (-f)
+  get ID      show one job
+  callbacks ID
+              callback deliveries of a job and their status
+  list        list jobs; filter with -status, -type, -priority, -label, -payload,
+              -data_prefix/-data_contains, -message_prefix/-message_contains,
+              -created_after/-created_before, -execution_after/-execution_before,
+              order with -sort, page with -limit and -cursor or -all
+  cancel ID   cancel a queued job
+  retry ID    requeue a failed, dead-lettered or cancelled job
+  dlq list    list dead-lettered jobs
+  dlq replay ID
+              resubmit a dead-lettered job as a new job
+  stats       job counts by status and queue depths
+  schedules   queued jobs whose execution time is in the future
+  breakers    circuit breakers that are open, half-open or counting failures
+
+Global flags:
+  -profile NAME    profile from the config file ($JOBCTL_CONFIG or
+                   ~/.config/jobctl/config.yaml)
+  -endpoint URL    API endpoint, overrides the profile ($JOBCTL_ENDPOINT)
+  -api-key KEY     API key, overrides the profile ($JOBCTL_API_KEY)
+  -token TOKEN     bearer token, overrides the profile ($JOBCTL_TOKEN)
+  -o FORMAT        table, json or yaml (also accepted after the command)
+`
+
+type command struct {
+	client *Client
+	output OUTPUT_FORMAT
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "jobctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("jobctl", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	profileName := global.String("profile", "", "")
+	var overrides Profile
+	global.StringVar(&overrides.Endpoint, "endpoint", "", "")
+	global.StringVar(&overrides.APIKey, "api-key", "", "")
+	global.StringVar(&overrides.Token, "token", "", "")
+	output := global.String("o", string(OUTPUT_TABLE), "")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return flag.ErrHelp
+	}
+
+	profile, err := resolveProfile(*profileName, overrides)
+	if err != nil {
+		return err
+	}
+	cmd := &command{client: NewClient(profile), output: OUTPUT_FORMAT(*output)}
+
+	name, rest := global.Arg(0), global.Args()[1:]
+	switch name {
+	case "submit":
+		return cmd.submit(rest)
+	case "get":
+		return cmd.get(rest)
+	case "callbacks":
+		return cmd.callbacks(rest)
+	case "list":
+		return cmd.list(rest)
+	case "cancel":
+		return cmd.action(rest, "cancel", "/job/%d/cancel")
+	case "retry":
+		return cmd.action(rest, "retry", "/job/%d/retry")
+	case "dlq":
+		if len(rest) > 0 && rest[0] == "list" {
+			return cmd.listJobs(rest[1:], "dlq list", "/dead-letters", nil)
+		}
+		if len(rest) > 0 && rest[0] == "replay" {
+			return cmd.action(rest[1:], "dlq replay", "/dead-letters/%d/replay")
+		}
+		return errors.New("usage: jobctl dlq list | dlq replay ID")
+	case "stats":
+		return cmd.stats(rest)
+	case "schedules":
+		return cmd.listJobs(rest, "schedules", "/schedules", nil)
+	case "breakers":
+		return cmd.breakers(rest)
+	default:
+		global.Usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+// flags returns a FlagSet for a subcommand that also accepts -o.
+func (cmd *command) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("jobctl "+name, flag.ContinueOnError)
+	fs.Func("o", "output format: table, json or yaml", func(value string) error {
+		cmd.output = OUTPUT_FORMAT(value)
+		return nil
+	})
+	return fs
+}
+
+func jobIDArg(fs *flag.FlagSet) (int, error) {
+	if fs.NArg() != 1 {
+		return 0, fmt.Errorf("usage: %s ID", fs.Name())
+	}
+	jobID, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		return 0, fmt.Errorf("invalid job id %q", fs.Arg(0))
+	}
+	return jobID, nil
+}
+
+func (cmd *command) submit(args []string) error {
+	fs := cmd.flags("submit")
+	file := fs.String("f", "", "JSON file with the job body; - reads stdin")
+	var body models.JobBody
+	fs.StringVar((*string)(&body.Type), "type", "", "job type, e.g. Email")
+	fs.StringVar((*string)(&body.Priority), "priority", string(models.JOB_PRIORITY_MEDIUM), "HIGH, MEDIUM or LOW")
+	fs.IntVar(&body.Delay, "delay", 0, "seconds to wait before the job is due")
+	payload := fs.String("payload", "", "payload as a JSON object")
+	var legacy models.PayloadType
+	fs.StringVar(&legacy.Data, "data", "", "payload data, for the two-field payload shape")
+	fs.StringVar(&legacy.Message, "message", "", "payload message, for the two-field payload shape")
+	fs.Func("label", "key=value label; repeatable", func(value string) error {
+		key, val, found := strings.Cut(value, "=")
+		if !found {
+			return fmt.Errorf("label %q: want key=value", value)
+		}
+		if body.Labels == nil {
+			body.Labels = map[string]string{}
+		}
+		body.Labels[key] = val
+		return nil
+	})
+	fs.Func("retry", `retry policy override as JSON, e.g. {"max_attempts":3,"strategy":"fixed"}`, func(value string) error {
+		body.Retry = &models.RetryPolicy{}
+		return json.Unmarshal([]byte(value), body.Retry)
+	})
+	fs.StringVar(&body.CallbackURL, "callback-url", "", "URL notified when the job completes, fails or is dead-lettered")
+	fs.Func("callback-event", "completed, failed or dead_lettered; repeatable, default all", func(value string) error {
+		body.CallbackEvents = append(body.CallbackEvents, models.CALLBACK_EVENT(value))
+		return nil
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *file != "" {
+		reader := os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			reader = f
+		}
+		body = models.JobBody{}
+		if err := json.NewDecoder(reader).Decode(&body); err != nil {
+			return fmt.Errorf("parse %s: %w", *file, err)
+		}
+	} else if body.Type == "" {
+		return errors.New("submit needs -type or -f")
+	} else if *payload != "" {
+		if !json.Valid([]byte(*payload)) {
+			return errors.New("-payload is not valid JSON")
+		}
+		body.Payload = json.RawMessage(*payload)
+	} else {
+		raw, err := json.Marshal(legacy)
+		if err != nil {
+			return err
+		}
+		body.Payload = raw
+	}
+
+	var response jobAction
+	if err := cmd.client.Do(http.MethodPost, "/submit-job", nil, body, &response); err != nil {
+		return err
+	}
+	return render(cmd.output, response, actionTable(response))
+}
+
+func (cmd *command) get(args []string) error {
+	fs := cmd.flags("get")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	var job models.Job
+	if err := cmd.client.Do(http.MethodGet, fmt.Sprintf("/job/%d", jobID), nil, nil, &job); err != nil {
+		return err
+	}
+	return render(cmd.output, job, jobTable(job))
+}
+
+func (cmd *command) list(args []string) error {
+	fs := cmd.flags("list")
+	query := url.Values{}
+	for _, param := range []string{"status", "type", "priority", "sort", "cursor",
+		"created_after", "created_before", "execution_after", "execution_before",
+		"data_prefix", "data_contains", "message_prefix", "message_contains"} {
+		param := param
+		fs.Func(param, "value for the "+param+" query parameter of GET /apis/v1/jobs", func(value string) error {
+			query.Set(param, value)
+			return nil
+		})
+	}
+	fs.Func("label", "key:value label filter; repeatable", func(value string) error {
+		query.Add("label", value)
+		return nil
+	})
+	fs.Func("payload", "path=value payload filter, e.g. customer.id=123; repeatable", func(value string) error {
+		path, val, found := strings.Cut(value, "=")
+		if !found {
+			return fmt.Errorf("payload filter %q: want path=value", value)
+		}
+		query.Add("payload."+path, val)
+		return nil
+	})
+	limit := fs.Int("limit", 0, "page size")
+	all := fs.Bool("all", false, "follow next_cursor until every page is fetched")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *limit > 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+
+	var page jobPage
+	for {
+		var next jobPage
+		if err := cmd.client.Do(http.MethodGet, "/jobs", query, nil, &next); err != nil {
+			return err
+		}
+		page.Jobs = append(page.Jobs, next.Jobs...)
+		page.NextCursor = next.NextCursor
+		if !*all || next.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", next.NextCursor)
+	}
+
+	if err := render(cmd.output, page, jobsTable(page.Jobs)); err != nil {
+		return err
+	}
+	if cmd.output == OUTPUT_TABLE && page.NextCursor != "" {
+		fmt.Fprintf(os.Stderr, "more results: -cursor %s\n", page.NextCursor)
+	}
+	return nil
+}
+
+func (cmd *command) listJobs(args []string, name, path string, query url.Values) error {
+	fs := cmd.flags(name)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return cmd.fetchJobs(path, query)
+}
+
+func (cmd *command) fetchJobs(path string, query url.Values) error {
+	jobs := []models.Job{}
+	if err := cmd.client.Do(http.MethodGet, path, query, nil, &jobs); err != nil {
+		return err
+	}
+	return render(cmd.output, jobs, jobsTable(jobs))
+}
+
+func (cmd *command) action(args []string, name, pathFormat string) error {
+	fs := cmd.flags(name)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	var response jobAction
+	if err := cmd.client.Do(http.MethodPost, fmt.Sprintf(pathFormat, jobID), nil, nil, &response); err != nil {
+		return err
+	}
+	return render(cmd.output, response, actionTable(response))
+}
+
+func (cmd *command) stats(args []string) error {
+	fs := cmd.flags("stats")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var s stats
+	if err := cmd.client.Do(http.MethodGet, "/stats", nil, nil, &s); err != nil {
+		return err
+	}
+	return render(cmd.output, s, statsTable(s))
+}
+
+func (cmd *command) breakers(args []string) error {
+	fs := cmd.flags("breakers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	breakers := []circuitBreaker{}
+	if err := cmd.client.Do(http.MethodGet, "/circuit-breakers", nil, nil, &breakers); err != nil {
+		return err
+	}
+	return render(cmd.output, breakers, breakersTable(breakers))
+}
+
+func (cmd *command) callbacks(args []string) error {
+	fs := cmd.flags("callbacks")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	jobID, err := jobIDArg(fs)
+	if err != nil {
+		return err
+	}
+
+	var response callbackDeliveries
+	if err := cmd.client.Do(http.MethodGet, fmt.Sprintf("/job/%d/callbacks", jobID), nil, nil, &response); err != nil {
+		return err
+	}
+	return render(cmd.output, response, callbacksTable(response.Deliveries))
+}
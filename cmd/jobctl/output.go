@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+type OUTPUT_FORMAT string
+
+const (
+	OUTPUT_TABLE OUTPUT_FORMAT = "table"
+	OUTPUT_JSON  OUTPUT_FORMAT = "json"
+	OUTPUT_YAML  OUTPUT_FORMAT = "yaml"
+)
+
+// render writes value in the requested format. table is only called for
+// OUTPUT_TABLE and writes tab-separated rows.
+func render(format OUTPUT_FORMAT, value interface{}, table func(w io.Writer)) error {
+	switch format {
+	case OUTPUT_JSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case OUTPUT_YAML:
+		// Round-trip through JSON so YAML keys match the API's field names.
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(raw, &generic); err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		defer encoder.Close()
+		return encoder.Encode(generic)
+	case OUTPUT_TABLE:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q (want table, json or yaml)", format)
+	}
+}
+
+const timeLayout = "2006-01-02 15:04:05"
+
+func jobsTable(jobs []models.Job) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tTENANT\tTYPE\tPRIORITY\tSTATUS\tATTEMPTS\tEXECUTION AT\tCREATED AT")
+		for _, job := range jobs {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+				job.ID, job.TenantID, job.Type, job.Priority, job.Status, job.Attempts,
+				job.ExecutionAt.Local().Format(timeLayout), job.CreatedAt.Local().Format(timeLayout))
+		}
+	}
+}
+
+func jobTable(job models.Job) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintf(w, "ID:\t%d\n", job.ID)
+		fmt.Fprintf(w, "Tenant:\t%s\n", job.TenantID)
+		fmt.Fprintf(w, "Type:\t%s\n", job.Type)
+		fmt.Fprintf(w, "Priority:\t%s\n", job.Priority)
+		fmt.Fprintf(w, "Status:\t%s\n", job.Status)
+		fmt.Fprintf(w, "Attempts:\t%d\n", job.Attempts)
+		fmt.Fprintf(w, "Last error:\t%s\n", job.LastError)
+		fmt.Fprintf(w, "Created at:\t%s\n", job.CreatedAt.Local().Format(timeLayout))
+		fmt.Fprintf(w, "Execution at:\t%s\n", job.ExecutionAt.Local().Format(timeLayout))
+		if job.Progress != nil {
+			fmt.Fprintf(w, "Progress:\t%d%% %s %s (updated %s)\n", job.Progress.Percent, job.Progress.Stage,
+				job.Progress.Message, job.Progress.UpdatedAt.Local().Format(timeLayout))
+		}
+		if job.CallbackURL != "" {
+			fmt.Fprintf(w, "Callback:\t%s (%s)\n", job.CallbackURL, strings.Join(job.CallbackEvents, ", "))
+		}
+		fmt.Fprintf(w, "Payload:\t%s\n", job.Payload)
+	}
+}
+
+type queueDepth struct {
+	Total int64 `json:"total"`
+	Due   int64 `json:"due"`
+}
+
+type jobPage struct {
+	Jobs       []models.Job `json:"jobs"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type stats struct {
+	TenantID string                `json:"tenant_id"`
+	Statuses map[string]int        `json:"statuses"`
+	Queues   map[string]queueDepth `json:"queues"`
+}
+
+func statsTable(s stats) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintf(w, "TENANT %s\n\n", s.TenantID)
+		fmt.Fprintln(w, "STATUS\tJOBS")
+		for _, status := range sortedKeys(s.Statuses) {
+			fmt.Fprintf(w, "%s\t%d\n", status, s.Statuses[status])
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "QUEUE\tQUEUED\tDUE")
+		for _, priority := range []models.JOB_PRIORITY{models.JOB_PRIORITY_HIGH, models.JOB_PRIORITY_MEDIUM, models.JOB_PRIORITY_LOW} {
+			depth := s.Queues[string(priority)]
+			fmt.Fprintf(w, "%s\t%d\t%d\n", priority, depth.Total, depth.Due)
+		}
+	}
+}
+
+type circuitBreaker struct {
+	Type        string     `json:"type"`
+	Destination string     `json:"destination,omitempty"`
+	State       string     `json:"state"`
+	Failures    int        `json:"failures"`
+	OpenedAt    *time.Time `json:"opened_at,omitempty"`
+	OpenUntil   *time.Time `json:"open_until,omitempty"`
+}
+
+func breakersTable(breakers []circuitBreaker) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "TYPE\tDESTINATION\tSTATE\tFAILURES\tOPEN UNTIL")
+		for _, b := range breakers {
+			openUntil := ""
+			if b.OpenUntil != nil {
+				openUntil = b.OpenUntil.Local().Format(timeLayout)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", b.Type, b.Destination, b.State, b.Failures, openUntil)
+		}
+	}
+}
+
+type callbackDelivery struct {
+	ID            int64      `json:"id"`
+	Event         string     `json:"event"`
+	URL           string     `json:"url"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ResponseCode  *int       `json:"response_code,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+type callbackDeliveries struct {
+	JobID      int                `json:"job_id"`
+	Deliveries []callbackDelivery `json:"deliveries"`
+}
+
+func callbacksTable(deliveries []callbackDelivery) func(io.Writer) {
+	return func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tEVENT\tSTATUS\tATTEMPTS\tRESPONSE\tNEXT ATTEMPT\tLAST ERROR")
+		for _, d := range deliveries {
+			response, next := "", ""
+			if d.ResponseCode != nil {
+				response = strconv.Itoa(*d.ResponseCode)
+			}
+			if d.NextAttemptAt != nil {
+				next = d.NextAttemptAt.Local().Format(timeLayout)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n", d.ID, d.Event, d.Status, d.Attempts, response, next, d.LastError)
+		}
+	}
+}
+
+type jobAction struct {
+	JobID    int    `json:"job_id"`
+	NewJobID int    `json:"new_job_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+func actionTable(action jobAction) func(io.Writer) {
+	return func(w io.Writer) {
+		if action.NewJobID != 0 {
+			fmt.Fprintf(w, "job %d replayed as job %d (%s)\n", action.JobID, action.NewJobID, action.Status)
+			return
+		}
+		fmt.Fprintf(w, "job %d %s\n", action.JobID, action.Status)
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,94 +1,339 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/callback"
 	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobtypes"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/lifecycle"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/metrics"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/queue"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/quota"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/retry"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/tracing"
 	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
-	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+type submitJobResponse struct {
+	JobID  int               `json:"job_id"`
+	Status models.JOB_STATUS `json:"status"`
+}
+
 func (handler *ApiHandler) SubmitJob(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := config.LoggerFromContext(ctx)
 	sugar := logger.Sugar()
+	tenantID := config.TenantFromContext(ctx)
 
-	sugar.Info("Received job submission")
+	sugar.Infow("Received job submission", "tenant_id", tenantID)
+
+	// Cap the body before decoding so an oversized payload is never read
+	// into memory only to be rejected by the quota check.
+	if maxPayload := handler.Settings.Get().QuotaForTenant(tenantID).MaxPayloadBytes; maxPayload > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(maxPayload+config.SUBMISSION_BODY_OVERHEAD_BYTES))
+	}
 
 	var body models.JobBody
 	decoder := json.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	if err := decoder.Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sugar.Warnw("Rejected oversized submission", "limit_bytes", tooLarge.Limit)
+			http.Error(w, fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
 		sugar.Warnf("Failed to decode request body: %v", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if body.Payload.Data == "" || body.Payload.Message == "" {
-		sugar.Warnf("Data or Message is empty")
-		http.Error(w, "Data or Message is empty", http.StatusBadRequest)
+	if body.Priority == "" {
+		body.Priority = models.JOB_PRIORITY_MEDIUM
+	}
+	if fieldErrors := validateSubmission(body, handler.Settings.Get()); len(fieldErrors) > 0 {
+		sugar.Warnw("Rejected invalid submission", "type", body.Type, "errors", fieldErrors)
+		writeFieldErrors(w, submissionErrors{Errors: fieldErrors})
 		return
 	}
+	if body.Labels == nil {
+		body.Labels = map[string]string{}
+	}
+	var callbackURL *string
+	callbackEvents := []string{}
+	if body.CallbackURL != "" {
+		callbackURL = &body.CallbackURL
+		callbackEvents = toStrings(body.CallbackEvents)
+		if len(callbackEvents) == 0 {
+			callbackEvents = toStrings(callback.Events)
+		}
+	}
+
+	definition, _ := jobtypes.Lookup(body.Type)
+	if err := definition.Validate(body.Payload); err != nil {
+		var invalid *jobtypes.ValidationError
+		if !errors.As(err, &invalid) {
+			logger.Error("Failed to validate payload", zap.Error(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		sugar.Warnw("Payload failed schema validation", "type", body.Type, "errors", invalid.Fields)
+		writeFieldErrors(w, invalid)
+		return
+	}
+
+	principal, _ := auth.PrincipalFromContext(ctx)
+	if principal != nil && !principal.CanSubmitJobType(body.Type) {
+		sugar.Warnw("Job type not allowed for caller", "type", body.Type)
+		response := auth.NewForbiddenResponse(principal, "job type not in caller's allow-list")
+		response.JobType = body.Type
+		auth.WriteForbidden(w, response)
+		return
+	}
+
+	payloadBytes := len(body.Payload)
+	reservation, err := handler.QuotaEnforcer.Reserve(ctx, tenantID, payloadBytes)
+	if err != nil {
+		var exceeded *quota.ExceededError
+		if errors.As(err, &exceeded) {
+			sugar.Warnw("Tenant quota exceeded", "tenant_id", tenantID, "reason", exceeded.Reason)
+			if exceeded.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(exceeded.RetryAfter.Seconds()))))
+			}
+			http.Error(w, "Quota exceeded: "+exceeded.Reason, http.StatusTooManyRequests)
+			return
+		}
+		logger.Error("Failed to evaluate tenant quota", zap.Error(err))
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// A submission that fails from here on gives its quota back.
+	submitted := false
+	defer func() {
+		if !submitted {
+			reservation.Cancel(ctx)
+		}
+	}()
+
+	// With encryption enabled the payload column keeps only '{}' and the
+	// sealed payload goes into the payload_* columns.
+	storedPayload := string(body.Payload)
+	var sealed models.EncryptedPayload
+	if handler.Keyring != nil {
+		sealed, err = handler.Keyring.Seal(body.Payload, envelope.PayloadAAD(tenantID))
+		if err != nil {
+			logger.Error("Failed to encrypt payload", zap.Error(err))
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		storedPayload = "{}"
+	}
 
 	query := `
-		INSERT INTO jobs (type, data, message, priority, delay_seconds, created_at, execution_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id
+		INSERT INTO jobs (tenant_id, type, payload, priority, labels, delay_seconds, created_at, execution_at,
+			request_id, trace_context, payload_key_id, payload_dek, payload_ciphertext, retry_policy,
+			callback_url, callback_events)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10, NULLIF($11, ''), $12, $13, $14, $15, $16)
+		RETURNING id
 	`
 
 	createdAt := time.Now().UTC()
 	executionAt := createdAt.Add(time.Duration(body.Delay) * time.Second)
 
+	insertCtx, insertSpan := tracing.Tracer().Start(ctx, "postgres.insert_job", trace.WithAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("job.type", string(body.Type)),
+	))
 	var jobID int
-	err := handler.PostgresPool.QueryRow(ctx, query,
+	err = reservation.QueryRow(insertCtx, query,
+		tenantID,
 		body.Type,
-		body.Payload.Data,
-		body.Payload.Message,
+		storedPayload,
 		body.Priority,
+		body.Labels,
 		body.Delay,
 		createdAt,
 		executionAt,
+		config.RequestIDFromContext(ctx),
+		tracing.Inject(insertCtx),
+		sealed.KeyID,
+		sealed.WrappedKey,
+		sealed.Ciphertext,
+		body.Retry,
+		callbackURL,
+		callbackEvents,
 	).Scan(&jobID)
-
-	if err != nil {
-		logger.Error("Database insert failed", zap.Any("request_id", ctx.Value("request_id")))
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-		return
-	}
-
-	job := models.RedisJobType{
-		JobID:       jobID,
-		Type:        body.Type,
-		Payload:     body.Payload,
-		ExecutionAt: executionAt,
-		Priority:    body.Priority,
+	if err == nil {
+		err = reservation.Commit(insertCtx)
 	}
+	tracing.EndSpan(insertSpan, err)
 
-	jobJSON, err := json.Marshal(job)
 	if err != nil {
-		logger.Error("Failed to marshal job for Redis", zap.Error(err))
+		logger.Error("Database insert failed", zap.Error(err))
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
 
-	redisKey := fmt.Sprintf("job_%s", body.Priority)
-	score := float64(executionAt.Unix())
+	enqueueCtx, enqueueSpan := tracing.Tracer().Start(ctx, "redis.enqueue", trace.WithAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.Int("job.id", jobID),
+	))
+	defer enqueueSpan.End()
 
-	redisCmd := handler.RedisClient.ZAdd(ctx, redisKey, &redis.Z{
-		Score:  score,
-		Member: jobJSON,
-	})
-
-	if err := redisCmd.Err(); err != nil {
+	if err := jobops.Enqueue(enqueueCtx, handler.RedisClient, jobID, body.Priority, executionAt); err != nil {
+		tracing.RecordError(enqueueSpan, err)
 		logger.Error("Failed to push job to Redis", zap.Error(err))
+		// No worker can reach the row, so drop it rather than let it count
+		// against the tenant's queued jobs forever.
+		if _, err := handler.PostgresPool.Exec(context.WithoutCancel(ctx),
+			"DELETE FROM jobs WHERE id = $1 AND status = $2", jobID, models.JOB_STATUS_QUEUED); err != nil {
+			logger.Error("Failed to remove unqueued job", zap.Int("job_id", jobID), zap.Error(err))
+		}
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	submitted = true
 
+	lifecycle.NewLog(handler.RedisClient).Record(ctx, sugar, lifecycle.Event{
+		Event:    lifecycle.EVENT_SUBMITTED,
+		JobID:    jobID,
+		TenantID: tenantID,
+		Type:     body.Type,
+		Priority: body.Priority,
+		Status:   models.JOB_STATUS_QUEUED,
+	})
+	metrics.JobsSubmittedTotal.WithLabelValues(string(body.Type), string(body.Priority)).Inc()
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte("Job submitted successfully"))
+	json.NewEncoder(w).Encode(submitJobResponse{JobID: jobID, Status: models.JOB_STATUS_QUEUED})
 	logger.Info("Job inserted into database")
 }
+
+type submissionErrors struct {
+	Errors []jobtypes.FieldError `json:"errors"`
+}
+
+func writeFieldErrors(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(body)
+}
+
+// validateSubmission checks everything about a submission except the payload
+// contents, so a caller sees every problem with the envelope at once.
+// Callbacks are refused while no signing secret is configured.
+func validateSubmission(body models.JobBody, settings config.Config) []jobtypes.FieldError {
+	var fieldErrors []jobtypes.FieldError
+
+	if _, ok := jobtypes.Lookup(body.Type); !ok {
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{
+			Field:   "type",
+			Message: fmt.Sprintf("unknown job type %q", body.Type),
+			Allowed: toStrings(jobtypes.Types()),
+		})
+	}
+	if _, ok := IsValidJobPriority(string(body.Priority)); !ok {
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{
+			Field:   "priority",
+			Message: fmt.Sprintf("unknown priority %q", body.Priority),
+			Allowed: toStrings(queue.Priorities),
+		})
+	}
+	if body.Delay < 0 || body.Delay > config.MAX_JOB_DELAY_SECONDS {
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{
+			Field:   "delay",
+			Message: fmt.Sprintf("delay must be between 0 and %d seconds", config.MAX_JOB_DELAY_SECONDS),
+		})
+	}
+	if len(body.Payload) == 0 || string(body.Payload) == "null" {
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "payload", Message: "payload is required"})
+	}
+	if err := validateLabels(body.Labels); err != nil {
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "labels", Message: err.Error()})
+	}
+	if body.Retry != nil {
+		err := retry.Validate(*body.Retry)
+		if err == nil {
+			err = retry.CheckBounds(*body.Retry, settings.MaxJobRetryAttempts, settings.MinJobRetryDelaySec)
+		}
+		if err != nil {
+			fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "retry", Message: err.Error()})
+		}
+	}
+	switch {
+	case body.CallbackURL != "" && settings.CallbackSigningSecret == "":
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "callback_url", Message: "callbacks are not enabled on this server"})
+	case body.CallbackURL != "":
+		if err := validateCallbackURL(body.CallbackURL); err != nil {
+			fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "callback_url", Message: err.Error()})
+		}
+	case len(body.CallbackEvents) > 0:
+		fieldErrors = append(fieldErrors, jobtypes.FieldError{Field: "callback_events", Message: "callback_events needs a callback_url"})
+	}
+	for _, event := range body.CallbackEvents {
+		if !slices.Contains(callback.Events, event) {
+			fieldErrors = append(fieldErrors, jobtypes.FieldError{
+				Field:   "callback_events",
+				Message: fmt.Sprintf("unknown callback event %q", event),
+				Allowed: toStrings(callback.Events),
+			})
+			break
+		}
+	}
+	return fieldErrors
+}
+
+func validateCallbackURL(raw string) error {
+	if len(raw) > config.MAX_CALLBACK_URL_BYTES {
+		return fmt.Errorf("callback_url exceeds %d bytes", config.MAX_CALLBACK_URL_BYTES)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("callback_url must be an absolute http or https URL")
+	}
+	if err := callback.CheckHost(parsed.Hostname()); err != nil {
+		return fmt.Errorf("callback_url: %w", err)
+	}
+	return nil
+}
+
+func validateLabels(labels map[string]string) error {
+	if len(labels) > config.MAX_JOB_LABELS {
+		return fmt.Errorf("at most %d labels are allowed", config.MAX_JOB_LABELS)
+	}
+	for key, value := range labels {
+		if key == "" || len(key) > config.MAX_LABEL_KEY_BYTES || strings.Contains(key, ":") {
+			return fmt.Errorf("label key %q must be 1-%d bytes without ':'", key, config.MAX_LABEL_KEY_BYTES)
+		}
+		if len(value) > config.MAX_LABEL_VALUE_BYTES {
+			return fmt.Errorf("label %q value exceeds %d bytes", key, config.MAX_LABEL_VALUE_BYTES)
+		}
+	}
+	return nil
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = string(value)
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobtypes"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+type createAPIKeyBody struct {
+	TenantID        string            `json:"tenant_id"`
+	Name            string            `json:"name"`
+	Roles           []string          `json:"roles"`
+	AllowedJobTypes []models.JOB_TYPE `json:"allowed_job_types"`
+}
+
+type createAPIKeyResponse struct {
+	auth.APIKey
+	Key string `json:"key"`
+}
+
+func (handler *ApiHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := config.LoggerFromContext(ctx)
+	sugar := logger.Sugar()
+
+	var body createAPIKeyBody
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&body); err != nil {
+		sugar.Warnf("Failed to decode request body: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if body.Name == "" || len(body.Roles) == 0 {
+		http.Error(w, "name and roles are required", http.StatusBadRequest)
+		return
+	}
+
+	// Keys are created in the caller's tenant. Only a platform admin may
+	// name another one.
+	tenantID := config.TenantFromContext(ctx)
+	if body.TenantID != "" && body.TenantID != tenantID {
+		principal, _ := auth.PrincipalFromContext(ctx)
+		if principal == nil || !principal.IsPlatformAdmin() {
+			auth.WriteForbidden(w, auth.NewForbiddenResponse(principal, "cannot create api keys for another tenant"))
+			return
+		}
+		tenantID = body.TenantID
+	}
+
+	newKey := auth.APIKey{TenantID: tenantID, Name: body.Name}
+	for _, jobType := range body.AllowedJobTypes {
+		if _, ok := jobtypes.Lookup(jobType); !ok {
+			http.Error(w, "Unknown job type: "+string(jobType), http.StatusBadRequest)
+			return
+		}
+		newKey.AllowedJobTypes = append(newKey.AllowedJobTypes, jobType)
+	}
+	for _, roleStr := range body.Roles {
+		role, ok := auth.IsValidRole(roleStr)
+		if !ok {
+			http.Error(w, "Unknown role: "+roleStr, http.StatusBadRequest)
+			return
+		}
+		newKey.Roles = append(newKey.Roles, role)
+	}
+
+	key, plaintext, err := handler.KeyStore.Create(ctx, newKey)
+	if err != nil {
+		sugar.Errorf("Failed to create api key: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	sugar.Infow("Created api key", "key_id", key.ID, "key_tenant_id", key.TenantID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createAPIKeyResponse{APIKey: key, Key: plaintext})
+}
+
+// tenantScope returns the tenant whose keys and queue pauses the caller
+// manages: its own, or every tenant ("") for a platform admin.
+func tenantScope(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.IsPlatformAdmin() {
+		return ""
+	}
+	return config.TenantFromContext(r.Context())
+}
+
+func (handler *ApiHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+
+	keys, err := handler.KeyStore.List(ctx, tenantScope(r))
+	if err != nil {
+		sugar.Errorf("Failed to list api keys: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (handler *ApiHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+
+	keyID, err := strconv.Atoi(mux.Vars(r)["key_id"])
+	if err != nil {
+		http.Error(w, "Invalid key id", http.StatusBadRequest)
+		return
+	}
+
+	if err := handler.KeyStore.Revoke(ctx, tenantScope(r), keyID); err != nil {
+		if errors.Is(err, auth.ErrKeyNotFound) {
+			http.Error(w, "API key not found", http.StatusNotFound)
+			return
+		}
+		sugar.Errorf("Failed to revoke api key: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	sugar.Infow("Revoked api key", "key_id", keyID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+)
+
+func requestAs(method, body string, principal *auth.Principal) *http.Request {
+	r := httptest.NewRequest(method, "/apis/v1/admin/api-keys", strings.NewReader(body))
+	ctx := auth.WithPrincipal(r.Context(), principal)
+	ctx = context.WithValue(ctx, config.TenantKey, principal.TenantID)
+	return r.WithContext(ctx)
+}
+
+// The rejected requests never reach the key store, so the handler runs
+// without Postgres.
+func TestCreateAPIKeyRejects(t *testing.T) {
+	tenantAdmin := &auth.Principal{TenantID: "tenant-a", Method: auth.AUTH_METHOD_API_KEY, Roles: []auth.ROLE{auth.ROLE_ADMIN}}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"another tenant", `{"tenant_id":"tenant-b","name":"ci","roles":["admin"]}`, http.StatusForbidden},
+		{"unknown job type", `{"name":"ci","roles":["producer"],"allowed_job_types":["Fax"]}`, http.StatusBadRequest},
+		{"unknown role", `{"name":"ci","roles":["root"]}`, http.StatusBadRequest},
+		{"missing name", `{"roles":["producer"]}`, http.StatusBadRequest},
+	}
+	handler := &ApiHandler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.CreateAPIKey(w, requestAs(http.MethodPost, tt.body, tenantAdmin))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+func TestTenantScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      string
+	}{
+		{"tenant admin", &auth.Principal{TenantID: "tenant-a", Method: auth.AUTH_METHOD_API_KEY}, "tenant-a"},
+		{"jwt admin", &auth.Principal{TenantID: "tenant-b", Method: auth.AUTH_METHOD_JWT}, "tenant-b"},
+		{"bootstrap admin", &auth.Principal{TenantID: config.DEFAULT_TENANT, Method: auth.AUTH_METHOD_ADMIN_KEY}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tenantScope(requestAs(http.MethodGet, "", tt.principal)); got != tt.want {
+				t.Errorf("tenantScope = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/events"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+// StreamEvents serves the caller's job status changes as Server-Sent Events.
+// A client that reconnects with Last-Event-ID (or ?last_event_id= where it
+// cannot set headers) first receives the stored events it missed.
+func (handler *ApiHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.TenantID = config.TenantFromContext(ctx)
+
+	lastID := int64(0)
+	if s := r.Header.Get("Last-Event-ID"); s != "" || r.URL.Query().Has("last_event_id") {
+		if s == "" {
+			s = r.URL.Query().Get("last_event_id")
+		}
+		lastID, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || lastID < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Streams outlive the server's write timeout.
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		sugar.Errorf("Streaming not supported: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying so nothing committed in between is lost;
+	// live events already replayed are skipped.
+	sub := handler.Events.Subscribe(filter)
+	defer handler.Events.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	replayed := map[int64]bool{}
+	if lastID > 0 {
+		for {
+			page, err := events.Replay(ctx, handler.PostgresPool, filter, lastID, config.EVENT_REPLAY_PAGE_SIZE)
+			if err != nil {
+				sugar.Errorf("Failed to replay job events: %v", err)
+				return
+			}
+			for _, event := range page {
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+				replayed[event.ID] = true
+				lastID = event.ID
+			}
+			if len(page) < config.EVENT_REPLAY_PAGE_SIZE {
+				break
+			}
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(config.EVENT_KEEPALIVE_INTERVAL)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if replayed[event.ID] {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: job\ndata: %s\n\n", event.ID, data)
+	return err
+}
+
+func parseEventFilter(values url.Values) (events.Filter, error) {
+	var filter events.Filter
+	for _, s := range splitParams(values["job_id"]) {
+		jobID, err := strconv.Atoi(s)
+		if err != nil {
+			return filter, fmt.Errorf("invalid job_id %q", s)
+		}
+		filter.JobIDs = append(filter.JobIDs, jobID)
+	}
+	for _, s := range splitParams(values["type"]) {
+		filter.Types = append(filter.Types, models.JOB_TYPE(s))
+	}
+	for _, s := range splitParams(values["status"]) {
+		status, ok := IsValidJobStatus(s)
+		if !ok {
+			return filter, fmt.Errorf("invalid status %q", s)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	for _, s := range values["label"] {
+		key, value, found := strings.Cut(s, ":")
+		if !found || key == "" {
+			return filter, fmt.Errorf("invalid label %q: want key:value", s)
+		}
+		if filter.Labels == nil {
+			filter.Labels = map[string]string{}
+		}
+		filter.Labels[key] = value
+	}
+	return filter, nil
+}
@@ -1,21 +1,41 @@
 package api
 
 import (
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/breaker"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/events"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/quota"
 	"github.com/go-redis/redis/v8"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
 type ApiHandler struct {
-	Logger       *zap.Logger
-	PostgresPool *pgxpool.Pool
-	RedisClient  *redis.Client
+	Logger        *zap.Logger
+	Settings      *config.Live
+	PostgresPool  *pgxpool.Pool
+	RedisClient   *redis.Client
+	QuotaEnforcer *quota.Enforcer
+	KeyStore      *auth.KeyStore
+	Breakers      *breaker.Breakers
+	Events        *events.Hub
+	// Keyring is nil when payload encryption is disabled.
+	Keyring *envelope.Keyring
 }
 
 type AppContext struct {
-	Logger       *zap.Logger
-	PostgresPool *pgxpool.Pool
-	RedisClient  *redis.Client
+	Logger        *zap.Logger
+	Settings      *config.Live
+	PostgresPool  *pgxpool.Pool
+	RedisClient   *redis.Client
+	QuotaEnforcer *quota.Enforcer
+	KeyStore      *auth.KeyStore
+	Breakers      *breaker.Breakers
+	Events        *events.Hub
+	// Keyring is nil when payload encryption is disabled.
+	Keyring *envelope.Keyring
 }
 
 type Handler struct {
@@ -28,8 +48,14 @@ func NewHandler(ctx *AppContext) *Handler {
 
 func ReturnHandler(appCtx *AppContext) *ApiHandler {
 	return &ApiHandler{
-		Logger:       appCtx.Logger,
-		RedisClient:  appCtx.RedisClient,
-		PostgresPool: appCtx.PostgresPool,
+		Logger:        appCtx.Logger,
+		Settings:      appCtx.Settings,
+		RedisClient:   appCtx.RedisClient,
+		PostgresPool:  appCtx.PostgresPool,
+		QuotaEnforcer: appCtx.QuotaEnforcer,
+		KeyStore:      appCtx.KeyStore,
+		Breakers:      appCtx.Breakers,
+		Events:        appCtx.Events,
+		Keyring:       appCtx.Keyring,
 	}
 }
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/gorilla/mux"
+)
+
+type jobActionResponse struct {
+	JobID    int    `json:"job_id"`
+	NewJobID int    `json:"new_job_id,omitempty"`
+	Status   string `json:"status"`
+}
+
+func (handler *ApiHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	handler.jobAction(w, r, func(tenantID string, jobID int) (jobActionResponse, error) {
+		err := jobops.Cancel(r.Context(), handler.PostgresPool, handler.RedisClient, tenantID, jobID)
+		return jobActionResponse{JobID: jobID, Status: "cancelled"}, err
+	})
+}
+
+func (handler *ApiHandler) RetryJob(w http.ResponseWriter, r *http.Request) {
+	handler.jobAction(w, r, func(tenantID string, jobID int) (jobActionResponse, error) {
+		err := jobops.Retry(r.Context(), handler.PostgresPool, handler.RedisClient, tenantID, jobID)
+		return jobActionResponse{JobID: jobID, Status: "queued"}, err
+	})
+}
+
+func (handler *ApiHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	handler.jobAction(w, r, func(tenantID string, jobID int) (jobActionResponse, error) {
+		newID, err := jobops.Replay(r.Context(), handler.PostgresPool, handler.RedisClient, tenantID, jobID)
+		return jobActionResponse{JobID: jobID, NewJobID: newID, Status: "queued"}, err
+	})
+}
+
+func (handler *ApiHandler) jobAction(w http.ResponseWriter, r *http.Request, run func(tenantID string, jobID int) (jobActionResponse, error)) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+	tenantID := config.TenantFromContext(ctx)
+
+	jobID, err := strconv.Atoi(mux.Vars(r)["job_id"])
+	if err != nil {
+		sugar.Warnf("Failed to parse request: %v", err)
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	response, err := run(tenantID, jobID)
+	switch {
+	case errors.Is(err, jobops.ErrJobNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, jobops.ErrInvalidState):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		sugar.Errorf("Job action failed: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	sugar.Infow("Job action applied", "job_id", jobID, "status", response.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
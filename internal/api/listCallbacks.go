@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/callback"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/gorilla/mux"
+)
+
+type callbackDeliveriesResponse struct {
+	JobID      int                 `json:"job_id"`
+	Deliveries []callback.Delivery `json:"deliveries"`
+}
+
+func (handler *ApiHandler) ListCallbackDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := config.LoggerFromContext(ctx)
+	sugar := logger.Sugar()
+	tenantID := config.TenantFromContext(ctx)
+
+	jobID, err := strconv.Atoi(mux.Vars(r)["job_id"])
+	if err != nil {
+		sugar.Warnf("Failed to parse request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = handler.PostgresPool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1 AND tenant_id = $2)", jobID, tenantID,
+	).Scan(&exists)
+	if err != nil {
+		sugar.Error("Failed to fetch job", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	deliveries, err := callback.List(ctx, handler.PostgresPool, jobID)
+	if err != nil {
+		sugar.Error("Failed to fetch callback deliveries", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(callbackDeliveriesResponse{JobID: jobID, Deliveries: deliveries}); err != nil {
+		sugar.Error("Failed to encode callback deliveries to JSON", err)
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"strconv"
 
 	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
-	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
 	"github.com/gorilla/mux"
 )
 
@@ -14,6 +14,7 @@ func (handler *ApiHandler) ListJobByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := config.LoggerFromContext(ctx)
 	sugar := logger.Sugar()
+	tenantID := config.TenantFromContext(ctx)
 
 	vars := mux.Vars(r)
 	jobIDStr := vars["job_id"]
@@ -25,28 +26,13 @@ func (handler *ApiHandler) ListJobByID(w http.ResponseWriter, r *http.Request) {
 	}
 	sugar.Infof("Listing job-id %d", jobID)
 
-	query := `
-		SELECT id, type, data, message, priority, status, created_at, execution_at
-		FROM jobs
-		WHERE id = $1
-	`
-
-	var job models.Job
-	err = handler.PostgresPool.QueryRow(ctx, query, jobID).Scan(
-		&job.ID,
-		&job.Type,
-		&job.Data,
-		&job.Message,
-		&job.Priority,
-		&job.Status,
-		&job.CreatedAt,
-		&job.ExecutionAt,
-	)
+	job, err := jobops.Get(ctx, handler.PostgresPool, tenantID, jobID)
 	if err != nil {
 		sugar.Error("Failed to fetch job", err)
 		http.Error(w, "Job not found", http.StatusNotFound)
 		return
 	}
+	handler.revealPayload(ctx, &job)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(job); err != nil {
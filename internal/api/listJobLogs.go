@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/joblog"
+	"github.com/gorilla/mux"
+)
+
+type jobLogsResponse struct {
+	JobID int               `json:"job_id"`
+	Lines []json.RawMessage `json:"lines"`
+}
+
+func (handler *ApiHandler) ListJobLogs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := config.LoggerFromContext(ctx)
+	sugar := logger.Sugar()
+	tenantID := config.TenantFromContext(ctx)
+
+	jobID, err := strconv.Atoi(mux.Vars(r)["job_id"])
+	if err != nil {
+		sugar.Warnf("Failed to parse request: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var exists bool
+	err = handler.PostgresPool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1 AND tenant_id = $2)", jobID, tenantID,
+	).Scan(&exists)
+	if err != nil {
+		sugar.Error("Failed to fetch job", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+
+	lines, err := joblog.NewStore(handler.RedisClient).Fetch(ctx, jobID)
+	if err != nil {
+		sugar.Error("Failed to fetch job logs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	response := jobLogsResponse{JobID: jobID, Lines: make([]json.RawMessage, 0, len(lines))}
+	for _, line := range lines {
+		response.Lines = append(response.Lines, json.RawMessage(line))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		sugar.Error("Failed to encode job logs to JSON", err)
+	}
+}
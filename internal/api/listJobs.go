@@ -2,63 +2,226 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
 	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
 )
 
+// ListJobs returns one page of the caller's jobs. Query parameters:
+//
+//	status, type, priority   comma-separated or repeated; q is an alias for status
+//	created_after, created_before, execution_after, execution_before   RFC 3339
+//	label                    key:value, repeated; every label must match
+//	payload.<path>=value     payload field equality, dotted path for nested fields
+//	data_prefix, data_contains, message_prefix, message_contains   text search
+//	sort                     created_at, execution_at, updated_at or id; prefix - for descending
+//	limit, cursor            page size and the next_cursor of the previous page
 func (handler *ApiHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := config.LoggerFromContext(ctx)
 	sugar := logger.Sugar()
+	tenantID := config.TenantFromContext(ctx)
 
-	sugar.Info("Listing job submissions")
+	sugar.Infow("Listing job submissions", "tenant_id", tenantID)
 
-	stateParam := r.URL.Query().Get("q")
-	jobStatus, ok := IsValidJobStatus(stateParam)
-
-	query := "SELECT id, type, data, message, priority, status, created_at, execution_at FROM jobs"
-	var args []interface{}
-
-	if ok {
-		query += " WHERE status = $1"
-		args = append(args, jobStatus)
+	query, err := parseListQuery(r.URL.Query())
+	if err != nil {
+		sugar.Warnf("Invalid list query: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	// Sealed payloads are stored as '{}', so these filters would silently
+	// match nothing.
+	if handler.Keyring != nil && query.FiltersPayload() {
+		http.Error(w, "Payload and text filters are unavailable while payload encryption is enabled", http.StatusBadRequest)
+		return
+	}
+	query.TenantID = tenantID
 
-	rows, err := handler.PostgresPool.Query(ctx, query, args...)
+	page, err := jobops.List(ctx, handler.PostgresPool, query)
+	if errors.Is(err, jobops.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		sugar.Error("Failed to fetch jobs", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
-	defer rows.Close()
-
-	var jobs []models.Job
-	for rows.Next() {
-		var job models.Job
-		if err := rows.Scan(
-			&job.ID, &job.Type, &job.Data, &job.Message, &job.Priority,
-			&job.Status, &job.CreatedAt, &job.ExecutionAt,
-		); err != nil {
-			sugar.Error("Failed to scan job row", err)
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
-			return
+	handler.listPayloads(ctx, page.Jobs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func parseListQuery(values url.Values) (jobops.ListQuery, error) {
+	var query jobops.ListQuery
+
+	for _, s := range splitParams(append(values["status"], values["q"]...)) {
+		status, ok := IsValidJobStatus(s)
+		if !ok {
+			return query, fmt.Errorf("invalid status %q", s)
+		}
+		query.Statuses = append(query.Statuses, status)
+	}
+	for _, s := range splitParams(values["type"]) {
+		query.Types = append(query.Types, models.JOB_TYPE(s))
+	}
+	for _, s := range splitParams(values["priority"]) {
+		priority, ok := IsValidJobPriority(s)
+		if !ok {
+			return query, fmt.Errorf("invalid priority %q", s)
 		}
-		jobs = append(jobs, job)
+		query.Priorities = append(query.Priorities, priority)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jobs)
+	ranges := []struct {
+		param  string
+		target *time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"execution_after", &query.ExecutionAfter},
+		{"execution_before", &query.ExecutionBefore},
+	}
+	for _, r := range ranges {
+		s := values.Get(r.param)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return query, fmt.Errorf("invalid %s: want RFC 3339 time", r.param)
+		}
+		*r.target = t
+	}
+
+	for _, s := range values["label"] {
+		key, value, found := strings.Cut(s, ":")
+		if !found || key == "" {
+			return query, fmt.Errorf("invalid label %q: want key:value", s)
+		}
+		if query.Labels == nil {
+			query.Labels = map[string]string{}
+		}
+		query.Labels[key] = value
+	}
+
+	if err := parsePayloadFilters(values, &query); err != nil {
+		return query, err
+	}
+
+	if s := values.Get("sort"); s != "" {
+		field, ok := jobops.IsValidSortField(strings.TrimPrefix(s, "-"))
+		if !ok {
+			return query, fmt.Errorf("invalid sort %q", s)
+		}
+		query.Sort, query.Descending = field, strings.HasPrefix(s, "-")
+	}
+
+	if s := values.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit <= 0 || limit > config.MAX_LIST_LIMIT {
+			return query, fmt.Errorf("limit must be between 1 and %d", config.MAX_LIST_LIMIT)
+		}
+		query.Limit = limit
+	}
+	query.Cursor = values.Get("cursor")
+	return query, nil
+}
+
+var payloadPathSegment = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// parsePayloadFilters reads payload.<path> equality filters and text
+// searches. Query shapes are limited so each one is served by an index.
+func parsePayloadFilters(values url.Values, query *jobops.ListQuery) error {
+	for param, paramValues := range values {
+		path, ok := strings.CutPrefix(param, "payload.")
+		if !ok {
+			continue
+		}
+		segments := strings.Split(path, ".")
+		if len(segments) > config.MAX_PAYLOAD_FILTER_DEPTH {
+			return fmt.Errorf("%s: payload paths are limited to %d levels", param, config.MAX_PAYLOAD_FILTER_DEPTH)
+		}
+		for _, segment := range segments {
+			if !payloadPathSegment.MatchString(segment) {
+				return fmt.Errorf("%s: path segments may only contain letters, digits, '_' and '-'", param)
+			}
+		}
+		for _, value := range paramValues {
+			if len(value) > config.MAX_FILTER_VALUE_BYTES {
+				return fmt.Errorf("%s: value exceeds %d bytes", param, config.MAX_FILTER_VALUE_BYTES)
+			}
+			query.PayloadMatches = append(query.PayloadMatches, jobops.PayloadMatch{Path: segments, Value: value})
+		}
+	}
+	if len(query.PayloadMatches) > config.MAX_PAYLOAD_FILTERS {
+		return fmt.Errorf("at most %d payload filters are allowed", config.MAX_PAYLOAD_FILTERS)
+	}
+
+	searches := []struct {
+		param  string
+		target *string
+	}{
+		{"data_prefix", &query.DataPrefix},
+		{"data_contains", &query.DataContains},
+		{"message_prefix", &query.MessagePrefix},
+		{"message_contains", &query.MessageContains},
+	}
+	for _, search := range searches {
+		s := values.Get(search.param)
+		if s == "" {
+			continue
+		}
+		if len(s) < config.MIN_CONTAINS_SEARCH_LEN || len(s) > config.MAX_FILTER_VALUE_BYTES {
+			return fmt.Errorf("%s must be %d-%d bytes", search.param, config.MIN_CONTAINS_SEARCH_LEN, config.MAX_FILTER_VALUE_BYTES)
+		}
+		*search.target = s
+	}
+	return nil
+}
+
+// splitParams flattens repeated and comma-separated query values.
+func splitParams(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
 }
 
 func IsValidJobStatus(s string) (models.JOB_STATUS, bool) {
 	status := models.JOB_STATUS(s)
 	switch status {
 	case models.JOB_STATUS_QUEUED, models.JOB_STATUS_PROGRESS,
-		models.JOB_STATUS_COMPLETED, models.JOB_STATUS_FAILED:
+		models.JOB_STATUS_COMPLETED, models.JOB_STATUS_FAILED,
+		models.JOB_STATUS_DEAD_LETTER, models.JOB_STATUS_CANCELLED:
 		return status, true
 	default:
 		return "", false
 	}
 }
+
+func IsValidJobPriority(s string) (models.JOB_PRIORITY, bool) {
+	priority := models.JOB_PRIORITY(s)
+	switch priority {
+	case models.JOB_PRIORITY_HIGH, models.JOB_PRIORITY_MEDIUM, models.JOB_PRIORITY_LOW:
+		return priority, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+// revealPayload decrypts a job for a single-job read. A payload that cannot
+// be decrypted is redacted so the rest of the job is still returned.
+func (handler *ApiHandler) revealPayload(ctx context.Context, job *models.Job) {
+	if err := jobops.Reveal(handler.Keyring, job); err != nil {
+		config.LoggerFromContext(ctx).Sugar().Warnw("Failed to decrypt job payload", "job_id", job.ID, "error", err)
+		jobops.Redact(job)
+	}
+}
+
+// listPayloads prepares jobs for a list view: encrypted payloads are
+// decrypted for callers with jobs:decrypt and redacted for everyone else.
+// Plaintext payloads are returned as stored.
+func (handler *ApiHandler) listPayloads(ctx context.Context, jobs []models.Job) {
+	principal, _ := auth.PrincipalFromContext(ctx)
+	canDecrypt := principal != nil && principal.HasPermission(auth.PERMISSION_JOBS_DECRYPT)
+	for i := range jobs {
+		switch {
+		case jobs[i].Encrypted == nil:
+		case canDecrypt:
+			handler.revealPayload(ctx, &jobs[i])
+		default:
+			jobops.Redact(&jobs[i])
+		}
+	}
+}
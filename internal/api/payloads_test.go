@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+func TestListPayloads(t *testing.T) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := envelope.LoadKeyring("", "k1:"+base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey := make([]byte, 32)
+	if _, err := rand.Read(otherKey); err != nil {
+		t.Fatal(err)
+	}
+	otherKeyring, err := envelope.LoadKeyring("", "k1:"+base64.StdEncoding.EncodeToString(otherKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const payload = `{"to":"user@example.com"}`
+	plaintextJob := func() models.Job {
+		return models.Job{ID: 1, TenantID: "tenant-a", Payload: []byte(payload), Data: "user@example.com"}
+	}
+	encryptedJob := func(ring *envelope.Keyring) models.Job {
+		sealed, err := ring.Seal([]byte(payload), envelope.PayloadAAD("tenant-a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return models.Job{ID: 2, TenantID: "tenant-a", Encrypted: &sealed}
+	}
+
+	tests := []struct {
+		name         string
+		keyring      *envelope.Keyring
+		roles        []auth.ROLE
+		job          models.Job
+		wantPayload  string
+		wantRedacted bool
+	}{
+		{"plaintext, encryption disabled", nil, []auth.ROLE{auth.ROLE_PRODUCER}, plaintextJob(), payload, false},
+		{"plaintext, encryption enabled", keyring, []auth.ROLE{auth.ROLE_PRODUCER}, plaintextJob(), payload, false},
+		{"encrypted, caller cannot decrypt", keyring, []auth.ROLE{auth.ROLE_PRODUCER}, encryptedJob(keyring), "", true},
+		{"encrypted, caller can decrypt", keyring, []auth.ROLE{auth.ROLE_ADMIN}, encryptedJob(keyring), payload, false},
+		{"encrypted, data key does not unwrap", keyring, []auth.ROLE{auth.ROLE_ADMIN}, encryptedJob(otherKeyring), "", true},
+		{"encrypted, encryption since disabled", nil, []auth.ROLE{auth.ROLE_ADMIN}, encryptedJob(keyring), "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &ApiHandler{Keyring: tt.keyring}
+			ctx := auth.WithPrincipal(context.Background(), &auth.Principal{TenantID: "tenant-a", Roles: tt.roles})
+			jobs := []models.Job{tt.job}
+			handler.listPayloads(ctx, jobs)
+
+			if got := string(jobs[0].Payload); got != tt.wantPayload {
+				t.Errorf("payload = %q, want %q", got, tt.wantPayload)
+			}
+			if jobs[0].PayloadRedacted != tt.wantRedacted {
+				t.Errorf("redacted = %v, want %v", jobs[0].PayloadRedacted, tt.wantRedacted)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+// Unknown priorities are rejected before Redis is touched.
+func TestQueuePauseRejectsUnknownPriority(t *testing.T) {
+	operator := &auth.Principal{TenantID: "tenant-a", Method: auth.AUTH_METHOD_API_KEY, Roles: []auth.ROLE{auth.ROLE_OPERATOR}}
+	handler := &ApiHandler{}
+	for name, action := range map[string]http.HandlerFunc{"pause": handler.PauseQueue, "resume": handler.ResumeQueue} {
+		t.Run(name, func(t *testing.T) {
+			r := mux.SetURLVars(requestAs(http.MethodPost, "", operator), map[string]string{"priority": "urgent"})
+			w := httptest.NewRecorder()
+			action(w, r)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+		})
+	}
+}
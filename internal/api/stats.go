@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+type statsResponse struct {
+	TenantID string                                    `json:"tenant_id"`
+	Statuses map[string]int                            `json:"statuses"`
+	Queues   map[models.JOB_PRIORITY]jobops.QueueDepth `json:"queues"`
+}
+
+// Stats reports the caller's job counts by status alongside the depth of
+// the shared priority queues.
+func (handler *ApiHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+	tenantID := config.TenantFromContext(ctx)
+
+	statuses, err := jobops.StatusCounts(ctx, handler.PostgresPool, tenantID)
+	if err != nil {
+		sugar.Error("Failed to count jobs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	queues, err := jobops.QueueDepths(ctx, handler.RedisClient)
+	if err != nil {
+		sugar.Error("Failed to read queue depths", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statsResponse{TenantID: tenantID, Statuses: statuses, Queues: queues})
+}
+
+// ListCircuitBreakers reports every circuit breaker that is open, half-open
+// or counting failures. Breakers are shared by all tenants.
+func (handler *ApiHandler) ListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	sugar := config.LoggerFromContext(r.Context()).Sugar()
+
+	statuses, err := handler.Breakers.List(r.Context())
+	if err != nil {
+		sugar.Error("Failed to read circuit breakers", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+func (handler *ApiHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	handler.listFiltered(w, r, jobops.Filter{Status: models.JOB_STATUS_DEAD_LETTER, Limit: 500})
+}
+
+// ListSchedules returns the caller's queued jobs whose execution time is
+// still in the future, soonest first.
+func (handler *ApiHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	handler.listFiltered(w, r, jobops.Filter{Status: models.JOB_STATUS_QUEUED, ScheduledOnly: true, Limit: 500})
+}
+
+func (handler *ApiHandler) listFiltered(w http.ResponseWriter, r *http.Request, filter jobops.Filter) {
+	ctx := r.Context()
+	sugar := config.LoggerFromContext(ctx).Sugar()
+	filter.TenantID = config.TenantFromContext(ctx)
+
+	jobs, err := jobops.Recent(ctx, handler.PostgresPool, filter)
+	if err != nil {
+		sugar.Error("Failed to fetch jobs", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	handler.listPayloads(ctx, jobs)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
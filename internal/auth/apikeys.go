@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const API_KEY_PREFIX = "jq_"
+
+var ErrKeyNotFound = errors.New("api key not found")
+
+type APIKey struct {
+	ID              int               `json:"id"`
+	TenantID        string            `json:"tenant_id"`
+	Name            string            `json:"name"`
+	Roles           []ROLE            `json:"roles"`
+	AllowedJobTypes []models.JOB_TYPE `json:"allowed_job_types,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	RevokedAt       *time.Time        `json:"revoked_at,omitempty"`
+}
+
+// apiKeyRow mirrors the api_keys columns in the plain types pgx scans
+// arrays into.
+type apiKeyRow struct {
+	APIKey
+	roles    []string
+	jobTypes []string
+}
+
+func (row *apiKeyRow) toAPIKey() APIKey {
+	key := row.APIKey
+	key.Roles = make([]ROLE, 0, len(row.roles))
+	for _, role := range row.roles {
+		key.Roles = append(key.Roles, ROLE(role))
+	}
+	for _, jobType := range row.jobTypes {
+		key.AllowedJobTypes = append(key.AllowedJobTypes, models.JOB_TYPE(jobType))
+	}
+	return key
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		out = append(out, string(value))
+	}
+	return out
+}
+
+// KeyStore keeps static API keys in Postgres. Only the SHA-256 of a key is
+// stored; the plaintext is handed out once, at creation time.
+type KeyStore struct {
+	PostgresPool *pgxpool.Pool
+}
+
+func NewKeyStore(postgresPool *pgxpool.Pool) *KeyStore {
+	return &KeyStore{PostgresPool: postgresPool}
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return API_KEY_PREFIX + hex.EncodeToString(buf), nil
+}
+
+// Create stores a new key and returns it along with its plaintext value.
+func (store *KeyStore) Create(ctx context.Context, key APIKey) (APIKey, string, error) {
+	plaintext, err := generateKey()
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	err = store.PostgresPool.QueryRow(ctx, `
+		INSERT INTO api_keys (tenant_id, name, key_hash, roles, allowed_job_types, created_at)
+		VALUES ($1, $2, $3, $4, $5, now()) RETURNING id, created_at
+	`, key.TenantID, key.Name, HashKey(plaintext), toStrings(key.Roles), toStrings(key.AllowedJobTypes),
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("insert api key: %w", err)
+	}
+	return key, plaintext, nil
+}
+
+// Lookup resolves a plaintext key to its record. Revoked keys are reported as
+// ErrKeyNotFound so callers cannot tell them apart from unknown keys.
+func (store *KeyStore) Lookup(ctx context.Context, plaintext string) (APIKey, error) {
+	var row apiKeyRow
+	err := store.PostgresPool.QueryRow(ctx, `
+		SELECT id, tenant_id, name, roles, allowed_job_types, created_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, HashKey(plaintext)).Scan(&row.ID, &row.TenantID, &row.Name, &row.roles, &row.jobTypes, &row.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return APIKey{}, ErrKeyNotFound
+	}
+	if err != nil {
+		return APIKey{}, fmt.Errorf("lookup api key: %w", err)
+	}
+	return row.toAPIKey(), nil
+}
+
+// List returns tenantID's keys, or every tenant's keys when tenantID is
+// empty.
+func (store *KeyStore) List(ctx context.Context, tenantID string) ([]APIKey, error) {
+	rows, err := store.PostgresPool.Query(ctx, `
+		SELECT id, tenant_id, name, roles, allowed_job_types, created_at, revoked_at
+		FROM api_keys
+		WHERE $1 = '' OR tenant_id = $1
+		ORDER BY id
+	`, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var row apiKeyRow
+		if err := rows.Scan(&row.ID, &row.TenantID, &row.Name, &row.roles, &row.jobTypes, &row.CreatedAt, &row.RevokedAt); err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, row.toAPIKey())
+	}
+	return keys, rows.Err()
+}
+
+// Revoke revokes key id if it belongs to tenantID, or to any tenant when
+// tenantID is empty. A key of another tenant is reported as ErrKeyNotFound.
+func (store *KeyStore) Revoke(ctx context.Context, tenantID string, id int) error {
+	tag, err := store.PostgresPool.Exec(ctx,
+		"UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL AND ($2 = '' OR tenant_id = $2)",
+		id, tenantID)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+var (
+	ErrNoCredentials      = errors.New("no credentials provided")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// KeyLookup resolves a plaintext API key to its record, reporting unknown
+// and revoked keys as ErrKeyNotFound. *KeyStore is the implementation.
+type KeyLookup interface {
+	Lookup(ctx context.Context, plaintext string) (APIKey, error)
+}
+
+// Authenticator resolves the caller of a request from either an X-API-Key
+// header or an Authorization bearer token. Browsers may also send an API
+// key as the password of HTTP Basic credentials. JWKS may be nil, in which
+// case bearer tokens are rejected.
+type Authenticator struct {
+	Keys        KeyLookup
+	JWKS        *JWKS
+	AdminAPIKey string
+	JWTIssuer   string
+	JWTAudience string
+}
+
+func NewAuthenticator(cfg config.Config, keys KeyLookup) (*Authenticator, error) {
+	authenticator := &Authenticator{
+		Keys:        keys,
+		AdminAPIKey: cfg.AdminAPIKey,
+		JWTIssuer:   cfg.JWTIssuer,
+		JWTAudience: cfg.JWTAudience,
+	}
+	if cfg.JWKSFile != "" {
+		jwks, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.JWKS = jwks
+	}
+	return authenticator, nil
+}
+
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if key := r.Header.Get(config.API_KEY_HEADER); key != "" {
+		return a.authenticateAPIKey(r, key)
+	}
+
+	if _, password, ok := r.BasicAuth(); ok {
+		if password == "" {
+			return nil, ErrInvalidCredentials
+		}
+		return a.authenticateAPIKey(r, password)
+	}
+
+	header := r.Header.Get(config.AUTHORIZATION_HEADER)
+	if header == "" {
+		return nil, ErrNoCredentials
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if strings.HasPrefix(token, API_KEY_PREFIX) {
+		return a.authenticateAPIKey(r, token)
+	}
+	return a.authenticateJWT(token)
+}
+
+func (a *Authenticator) authenticateAPIKey(r *http.Request, key string) (*Principal, error) {
+	if a.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.AdminAPIKey)) == 1 {
+		return &Principal{
+			Subject:  "bootstrap-admin",
+			TenantID: config.DEFAULT_TENANT,
+			Method:   AUTH_METHOD_ADMIN_KEY,
+			Roles:    []ROLE{ROLE_ADMIN},
+		}, nil
+	}
+
+	record, err := a.Keys.Lookup(r.Context(), key)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{
+		Subject:         fmt.Sprintf("api_key:%d", record.ID),
+		TenantID:        record.TenantID,
+		Method:          AUTH_METHOD_API_KEY,
+		Roles:           record.Roles,
+		AllowedJobTypes: record.AllowedJobTypes,
+	}, nil
+}
+
+func (a *Authenticator) authenticateJWT(token string) (*Principal, error) {
+	if a.JWKS == nil {
+		return nil, ErrInvalidCredentials
+	}
+	claims, err := a.JWKS.Verify(token, a.JWTIssuer, a.JWTAudience)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	principal := &Principal{
+		Subject:  claims.Subject,
+		TenantID: claims.Tenant,
+		Method:   AUTH_METHOD_JWT,
+	}
+	for _, role := range claims.Roles {
+		principal.Roles = append(principal.Roles, ROLE(role))
+	}
+	for _, jobType := range claims.JobTypes {
+		principal.AllowedJobTypes = append(principal.AllowedJobTypes, models.JOB_TYPE(jobType))
+	}
+	return principal, nil
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeKeys stands in for KeyStore. Like KeyStore.Lookup it reports revoked
+// keys as ErrKeyNotFound.
+type fakeKeys map[string]APIKey
+
+var errLookupFailed = errors.New("connection refused")
+
+func (keys fakeKeys) Lookup(ctx context.Context, plaintext string) (APIKey, error) {
+	if plaintext == "jq_broken" {
+		return APIKey{}, errLookupFailed
+	}
+	key, ok := keys[plaintext]
+	if !ok || key.RevokedAt != nil {
+		return APIKey{}, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func ptr(s string) *string { return &s }
+
+func TestAuthenticate(t *testing.T) {
+	jwks, privateKey := writeTestJWKS(t)
+	revokedAt := time.Now().Add(-time.Hour)
+	authenticator := &Authenticator{
+		Keys: fakeKeys{
+			"jq_active":  {ID: 1, TenantID: "tenant-a", Roles: []ROLE{ROLE_PRODUCER}},
+			"jq_revoked": {ID: 2, TenantID: "tenant-a", Roles: []ROLE{ROLE_ADMIN}, RevokedAt: &revokedAt},
+		},
+		JWKS:        jwks,
+		AdminAPIKey: "bootstrap-secret",
+		JWTIssuer:   testIssuer,
+		JWTAudience: testAudience,
+	}
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		basic      *string
+		wantErr    error
+		wantTenant string
+		wantMethod AUTH_METHOD
+	}{
+		{name: "no credentials", wantErr: ErrNoCredentials},
+		{name: "active api key", header: config.API_KEY_HEADER, value: "jq_active", wantTenant: "tenant-a", wantMethod: AUTH_METHOD_API_KEY},
+		{name: "revoked api key", header: config.API_KEY_HEADER, value: "jq_revoked", wantErr: ErrInvalidCredentials},
+		{name: "unknown api key", header: config.API_KEY_HEADER, value: "jq_unknown", wantErr: ErrInvalidCredentials},
+		{name: "lookup failure is not a credential error", header: config.API_KEY_HEADER, value: "jq_broken", wantErr: errLookupFailed},
+		{name: "api key as bearer token", header: config.AUTHORIZATION_HEADER, value: "Bearer jq_active", wantTenant: "tenant-a", wantMethod: AUTH_METHOD_API_KEY},
+		{name: "revoked api key as bearer token", header: config.AUTHORIZATION_HEADER, value: "Bearer jq_revoked", wantErr: ErrInvalidCredentials},
+		{name: "api key as basic password", basic: ptr("jq_active"), wantTenant: "tenant-a", wantMethod: AUTH_METHOD_API_KEY},
+		{name: "empty basic password", basic: ptr(""), wantErr: ErrInvalidCredentials},
+		{name: "admin key", header: config.API_KEY_HEADER, value: "bootstrap-secret", wantTenant: config.DEFAULT_TENANT, wantMethod: AUTH_METHOD_ADMIN_KEY},
+		{name: "valid jwt", header: config.AUTHORIZATION_HEADER, value: "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, validClaims()), wantTenant: "tenant-a", wantMethod: AUTH_METHOD_JWT},
+		{name: "expired jwt", header: config.AUTHORIZATION_HEADER, value: "Bearer " + sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, expired), wantErr: ErrInvalidCredentials},
+		{name: "jwt alg mismatch", header: config.AUTHORIZATION_HEADER, value: "Bearer " + sign(t, jwt.SigningMethodHS256, "rsa-1", privateKey.N.Bytes(), validClaims()), wantErr: ErrInvalidCredentials},
+		{name: "non-bearer scheme", header: config.AUTHORIZATION_HEADER, value: "Token jq_active", wantErr: ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/apis/v1/jobs", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			if tt.basic != nil {
+				r.SetBasicAuth("admin", *tt.basic)
+			}
+
+			principal, err := authenticator.Authenticate(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if principal.TenantID != tt.wantTenant || principal.Method != tt.wantMethod {
+				t.Errorf("principal = %+v, want tenant %q via %s", principal, tt.wantTenant, tt.wantMethod)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithoutJWKS(t *testing.T) {
+	_, privateKey := writeTestJWKS(t)
+	authenticator := &Authenticator{Keys: fakeKeys{}}
+	r := httptest.NewRequest(http.MethodGet, "/apis/v1/jobs", nil)
+	r.Header.Set(config.AUTHORIZATION_HEADER, "Bearer "+sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, validClaims()))
+	if _, err := authenticator.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("err = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	K   string `json:"k"`
+}
+
+type verificationKey struct {
+	alg string
+	key interface{}
+}
+
+// JWKS holds the verification keys loaded from a local JSON Web Key Set
+// file. RSA keys verify RS256 tokens and symmetric ("oct") keys verify HS256.
+type JWKS struct {
+	keys map[string]verificationKey
+}
+
+func LoadJWKS(path string) (*JWKS, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read jwks file: %w", err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks file: %w", err)
+	}
+
+	jwks := &JWKS{keys: make(map[string]verificationKey, len(set.Keys))}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: %w", jwk.Kid, err)
+		}
+		jwks.keys[jwk.Kid] = key
+	}
+	if len(jwks.keys) == 0 {
+		return nil, errors.New("jwks file contains no signing keys")
+	}
+	return jwks, nil
+}
+
+func (jwk jsonWebKey) verificationKey() (verificationKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		if jwk.Alg != "" && jwk.Alg != "RS256" {
+			return verificationKey{}, fmt.Errorf("unsupported alg %s for RSA key", jwk.Alg)
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return verificationKey{}, fmt.Errorf("decode modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return verificationKey{}, fmt.Errorf("decode exponent: %w", err)
+		}
+		publicKey := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return verificationKey{alg: "RS256", key: publicKey}, nil
+	case "oct":
+		if jwk.Alg != "" && jwk.Alg != "HS256" {
+			return verificationKey{}, fmt.Errorf("unsupported alg %s for oct key", jwk.Alg)
+		}
+		secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+		if err != nil {
+			return verificationKey{}, fmt.Errorf("decode secret: %w", err)
+		}
+		return verificationKey{alg: "HS256", key: secret}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
+
+// Claims are the token claims the API understands on top of the registered
+// ones. The tenant claim is required; sub identifies the caller in logs.
+// Roles and JobTypes map onto Principal.Roles and Principal.AllowedJobTypes.
+type Claims struct {
+	Tenant   string   `json:"tenant"`
+	Roles    []string `json:"roles,omitempty"`
+	JobTypes []string `json:"job_types,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Verify parses a bearer token and checks its signature, expiry and, when
+// configured, its issuer and audience. The token's alg must match the alg of
+// the key named by its kid, which rules out algorithm confusion attacks.
+func (jwks *JWKS) Verify(token, issuer, audience string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "HS256"}),
+		jwt.WithExpirationRequired(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := jwks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if t.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("token alg %s does not match key alg %s", t.Method.Alg(), key.alg)
+		}
+		return key.key, nil
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Tenant == "" {
+		return nil, errors.New("token has no tenant claim")
+	}
+	return claims, nil
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "jobqueue"
+)
+
+var testHMACSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// writeTestJWKS writes a key set with an RSA key "rsa-1" and an HMAC key
+// "hmac-1" and loads it.
+func writeTestJWKS(t *testing.T) (*JWKS, *rsa.PrivateKey) {
+	t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := map[string][]jsonWebKey{"keys": {
+		{
+			Kty: "RSA", Kid: "rsa-1", Alg: "RS256", Use: "sig",
+			N: base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+		},
+		{Kty: "oct", Kid: "hmac-1", Alg: "HS256", K: base64.RawURLEncoding.EncodeToString(testHMACSecret)},
+	}}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return jwks, privateKey
+}
+
+func validClaims() Claims {
+	now := time.Now()
+	return Claims{
+		Tenant: "tenant-a",
+		Roles:  []string{string(ROLE_ADMIN)},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    testIssuer,
+			Audience:  jwt.ClaimStrings{testAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestJWKSVerify(t *testing.T) {
+	jwks, privateKey := writeTestJWKS(t)
+	// The RSA public key's modulus, used as an HMAC secret, is what an
+	// algorithm confusion attack signs with.
+	publicKeyBytes := privateKey.N.Bytes()
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr string
+	}{
+		{
+			name:  "valid RS256",
+			token: func() string { return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, validClaims()) },
+		},
+		{
+			name:  "valid HS256",
+			token: func() string { return sign(t, jwt.SigningMethodHS256, "hmac-1", testHMACSecret, validClaims()) },
+		},
+		{
+			name:    "HS256 token naming an RSA kid",
+			token:   func() string { return sign(t, jwt.SigningMethodHS256, "rsa-1", publicKeyBytes, validClaims()) },
+			wantErr: "does not match key alg",
+		},
+		{
+			name:    "RS256 token naming an HMAC kid",
+			token:   func() string { return sign(t, jwt.SigningMethodRS256, "hmac-1", privateKey, validClaims()) },
+			wantErr: "does not match key alg",
+		},
+		{
+			name:    "unknown kid",
+			token:   func() string { return sign(t, jwt.SigningMethodRS256, "rsa-2", privateKey, validClaims()) },
+			wantErr: "unknown key id",
+		},
+		{
+			name:    "missing kid",
+			token:   func() string { return sign(t, jwt.SigningMethodRS256, "", privateKey, validClaims()) },
+			wantErr: "unknown key id",
+		},
+		{
+			name:    "unsupported alg",
+			token:   func() string { return sign(t, jwt.SigningMethodHS512, "hmac-1", testHMACSecret, validClaims()) },
+			wantErr: "signing method HS512 is invalid",
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := validClaims()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, claims)
+			},
+			wantErr: "token is expired",
+		},
+		{
+			name: "no expiry",
+			token: func() string {
+				claims := validClaims()
+				claims.ExpiresAt = nil
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, claims)
+			},
+			wantErr: "exp claim is required",
+		},
+		{
+			name: "wrong issuer",
+			token: func() string {
+				claims := validClaims()
+				claims.Issuer = "https://other.example.com"
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, claims)
+			},
+			wantErr: "token has invalid issuer",
+		},
+		{
+			name: "wrong audience",
+			token: func() string {
+				claims := validClaims()
+				claims.Audience = jwt.ClaimStrings{"other"}
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, claims)
+			},
+			wantErr: "token has invalid audience",
+		},
+		{
+			name: "no tenant",
+			token: func() string {
+				claims := validClaims()
+				claims.Tenant = ""
+				return sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, claims)
+			},
+			wantErr: "no tenant claim",
+		},
+		{
+			name: "tampered payload",
+			token: func() string {
+				parts := strings.Split(sign(t, jwt.SigningMethodRS256, "rsa-1", privateKey, validClaims()), ".")
+				claims := validClaims()
+				claims.Tenant = "tenant-b"
+				raw, _ := json.Marshal(claims)
+				parts[1] = base64.RawURLEncoding.EncodeToString(raw)
+				return strings.Join(parts, ".")
+			},
+			wantErr: "verification error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := jwks.Verify(tt.token(), testIssuer, testAudience)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+				if claims.Tenant != "tenant-a" || claims.Subject != "user-1" {
+					t.Errorf("claims = %+v", claims)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
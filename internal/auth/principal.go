@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+type AUTH_METHOD string
+
+const (
+	AUTH_METHOD_API_KEY   AUTH_METHOD = "api_key"
+	AUTH_METHOD_JWT       AUTH_METHOD = "jwt"
+	AUTH_METHOD_ADMIN_KEY AUTH_METHOD = "admin_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject  string
+	TenantID string
+	Method   AUTH_METHOD
+	Roles    []ROLE
+	// AllowedJobTypes restricts which job types the principal may submit.
+	// Empty means unrestricted.
+	AllowedJobTypes []models.JOB_TYPE
+}
+
+// IsPlatformAdmin reports whether the principal may manage every tenant
+// rather than only its own. Only the bootstrap admin key can.
+func (p *Principal) IsPlatformAdmin() bool {
+	return p.Method == AUTH_METHOD_ADMIN_KEY
+}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, config.PrincipalKey, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(config.PrincipalKey).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+type ROLE string
+
+const (
+	ROLE_PRODUCER ROLE = "producer"
+	ROLE_OPERATOR ROLE = "operator"
+	ROLE_ADMIN    ROLE = "admin"
+)
+
+type PERMISSION string
+
+const (
+	PERMISSION_JOBS_SUBMIT PERMISSION = "jobs:submit"
+	PERMISSION_JOBS_READ   PERMISSION = "jobs:read"
+	PERMISSION_JOBS_RETRY  PERMISSION = "jobs:retry"
+	PERMISSION_JOBS_CANCEL PERMISSION = "jobs:cancel"
+	// PERMISSION_JOBS_DECRYPT shows payloads in list views, which are
+	// redacted otherwise. Single-job reads always include the payload.
+	PERMISSION_JOBS_DECRYPT   PERMISSION = "jobs:decrypt"
+	PERMISSION_QUEUES_PAUSE   PERMISSION = "queues:pause"
+	PERMISSION_KEYS_MANAGE    PERMISSION = "keys:manage"
+	PERMISSION_DASHBOARD_VIEW PERMISSION = "dashboard:view"
+)
+
+var rolePermissions = map[ROLE][]PERMISSION{
+	ROLE_PRODUCER: {
+		PERMISSION_JOBS_SUBMIT,
+		PERMISSION_JOBS_READ,
+	},
+	ROLE_OPERATOR: {
+		PERMISSION_JOBS_READ,
+		PERMISSION_JOBS_RETRY,
+		PERMISSION_JOBS_CANCEL,
+		PERMISSION_QUEUES_PAUSE,
+	},
+	ROLE_ADMIN: {
+		PERMISSION_JOBS_SUBMIT,
+		PERMISSION_JOBS_READ,
+		PERMISSION_JOBS_RETRY,
+		PERMISSION_JOBS_CANCEL,
+		PERMISSION_JOBS_DECRYPT,
+		PERMISSION_QUEUES_PAUSE,
+		PERMISSION_KEYS_MANAGE,
+		PERMISSION_DASHBOARD_VIEW,
+	},
+}
+
+func IsValidRole(s string) (ROLE, bool) {
+	role := ROLE(s)
+	_, ok := rolePermissions[role]
+	return role, ok
+}
+
+func (p *Principal) HasPermission(permission PERMISSION) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanSubmitJobType reports whether the principal's job-type allow-list
+// admits jobType. An empty allow-list admits every type.
+func (p *Principal) CanSubmitJobType(jobType models.JOB_TYPE) bool {
+	if len(p.AllowedJobTypes) == 0 {
+		return true
+	}
+	for _, allowed := range p.AllowedJobTypes {
+		if allowed == jobType {
+			return true
+		}
+	}
+	return false
+}
+
+// ForbiddenResponse is the body of every 403 returned by the API.
+type ForbiddenResponse struct {
+	Error              string            `json:"error"`
+	Reason             string            `json:"reason"`
+	RequiredPermission PERMISSION        `json:"required_permission,omitempty"`
+	JobType            models.JOB_TYPE   `json:"job_type,omitempty"`
+	Roles              []ROLE            `json:"roles"`
+	AllowedJobTypes    []models.JOB_TYPE `json:"allowed_job_types,omitempty"`
+}
+
+func NewForbiddenResponse(principal *Principal, reason string) ForbiddenResponse {
+	response := ForbiddenResponse{Error: "forbidden", Reason: reason, Roles: []ROLE{}}
+	if principal != nil {
+		response.Roles = append(response.Roles, principal.Roles...)
+		response.AllowedJobTypes = principal.AllowedJobTypes
+	}
+	return response
+}
+
+func WriteForbidden(w http.ResponseWriter, response ForbiddenResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(response)
+}
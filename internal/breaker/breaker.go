@@ -0,0 +1,275 @@
+// Package breaker implements circuit breakers keyed by job type and
+// destination. Breaker state lives in Redis so every worker sees the same
+// breakers; a closed breaker without failures is simply a missing key.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+)
+
+type STATE string
+
+const (
+	STATE_CLOSED    STATE = "closed"
+	STATE_OPEN      STATE = "open"
+	STATE_HALF_OPEN STATE = "half_open"
+)
+
+const keyPrefix = "breaker:"
+
+// stateTTL expires breakers nobody has touched for a day, so destinations
+// that are no longer used do not linger.
+const stateTTL = 24 * time.Hour
+
+func key(jobType models.JOB_TYPE, destination string) string {
+	if destination == "" {
+		return keyPrefix + string(jobType)
+	}
+	return keyPrefix + string(jobType) + ":" + destination
+}
+
+// breakerState is a breaker as stored in its Redis hash. Times are Unix
+// milliseconds, 0 when unset.
+type breakerState struct {
+	State      STATE
+	Failures   int
+	OpenedAt   int64
+	OpenUntil  int64
+	ProbeUntil int64
+}
+
+func parseState(fields map[string]string) breakerState {
+	s := breakerState{State: STATE(fields["state"])}
+	if s.State == "" {
+		s.State = STATE_CLOSED
+	}
+	s.Failures, _ = strconv.Atoi(fields["failures"])
+	s.OpenedAt, _ = strconv.ParseInt(fields["opened_at"], 10, 64)
+	s.OpenUntil, _ = strconv.ParseInt(fields["open_until"], 10, 64)
+	s.ProbeUntil, _ = strconv.ParseInt(fields["probe_until"], 10, 64)
+	return s
+}
+
+func (s breakerState) fields() map[string]interface{} {
+	fields := map[string]interface{}{"state": string(s.State), "failures": s.Failures}
+	for name, value := range map[string]int64{"opened_at": s.OpenedAt, "open_until": s.OpenUntil, "probe_until": s.ProbeUntil} {
+		if value != 0 {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// allow decides whether a job may pass at now. A closed breaker lets every
+// job through. An open one lets none through until its cool-down has
+// passed; the next job then becomes the half-open probe and holds a lease
+// until probe_until, after which another job may probe. next is the state to
+// store, and equals s when nothing changes. retryAt is when a refused caller
+// should try again.
+func (s breakerState) allow(now int64, probeTimeout time.Duration) (allowed bool, retryAt int64, next breakerState) {
+	switch s.State {
+	case STATE_OPEN:
+		if now < s.OpenUntil {
+			return false, s.OpenUntil, s
+		}
+	case STATE_HALF_OPEN:
+		if now < s.ProbeUntil {
+			return false, s.ProbeUntil, s
+		}
+	default:
+		return true, 0, s
+	}
+	next = s
+	next.State, next.ProbeUntil = STATE_HALF_OPEN, now+probeTimeout.Milliseconds()
+	return true, 0, next
+}
+
+// record applies one outcome. A success closes the breaker and resets its
+// failure count; a failure opens a half-open breaker, or a closed one that
+// reaches threshold consecutive failures. While open, outcomes of jobs
+// admitted earlier are ignored. changed is set when the breaker moved to a
+// different state.
+func (s breakerState) record(success bool, now int64, threshold int, openDuration time.Duration) (next breakerState, changed bool) {
+	switch {
+	case s.State == STATE_OPEN:
+		return s, false
+	case success:
+		return breakerState{State: STATE_CLOSED}, s.State != STATE_CLOSED
+	case s.State == STATE_CLOSED && s.Failures+1 < threshold:
+		s.Failures++
+		return s, false
+	}
+	return breakerState{
+		State:     STATE_OPEN,
+		Failures:  s.Failures + 1,
+		OpenedAt:  now,
+		OpenUntil: now + openDuration.Milliseconds(),
+	}, true
+}
+
+// maxUpdateAttempts bounds how often update retries when other workers keep
+// changing the breaker between its read and write.
+const maxUpdateAttempts = 10
+
+// update reads the breaker at key, applies fn and stores the result if it
+// differs, retrying if another worker changed the breaker in between. A
+// closed breaker without failures is deleted.
+func (b *Breakers) update(ctx context.Context, key string, fn func(breakerState) breakerState) error {
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		err := b.RedisClient.Watch(ctx, func(tx *redis.Tx) error {
+			fields, err := tx.HGetAll(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			current := parseState(fields)
+			next := fn(current)
+			if next == current {
+				return nil
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Del(ctx, key)
+				if next != (breakerState{State: STATE_CLOSED}) {
+					pipe.HSet(ctx, key, next.fields())
+					pipe.PExpire(ctx, key, stateTTL)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return errors.New("circuit breaker kept changing during update")
+}
+
+type Breakers struct {
+	Settings    *config.Live
+	RedisClient *redis.Client
+}
+
+func NewBreakers(settings *config.Live, redisClient *redis.Client) *Breakers {
+	return &Breakers{Settings: settings, RedisClient: redisClient}
+}
+
+// Allow reports whether a job of jobType bound for destination may run now.
+// When it may not, retryAt is when the breaker will next admit a job.
+func (b *Breakers) Allow(ctx context.Context, jobType models.JOB_TYPE, destination string) (allowed bool, retryAt time.Time, err error) {
+	settings := b.Settings.Get().CircuitBreakerForType(jobType)
+	if settings.FailureThreshold == 0 {
+		return true, time.Time{}, nil
+	}
+
+	var retryAtMs int64
+	err = b.update(ctx, key(jobType, destination), func(current breakerState) breakerState {
+		var next breakerState
+		allowed, retryAtMs, next = current.allow(time.Now().UnixMilli(), settings.ProbeTimeout)
+		return next
+	})
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("check circuit breaker: %w", err)
+	}
+	if allowed {
+		return true, time.Time{}, nil
+	}
+	return false, time.UnixMilli(retryAtMs), nil
+}
+
+// Record reports the outcome of a job Allow admitted and returns the
+// breaker's state afterwards. changed is set when the outcome moved the
+// breaker to that state.
+func (b *Breakers) Record(ctx context.Context, jobType models.JOB_TYPE, destination string, success bool) (state STATE, changed bool, err error) {
+	settings := b.Settings.Get().CircuitBreakerForType(jobType)
+	if settings.FailureThreshold == 0 {
+		return STATE_CLOSED, false, nil
+	}
+
+	err = b.update(ctx, key(jobType, destination), func(current breakerState) breakerState {
+		var next breakerState
+		next, changed = current.record(success, time.Now().UnixMilli(), settings.FailureThreshold, settings.OpenDuration)
+		state = next.State
+		return next
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("record circuit breaker outcome: %w", err)
+	}
+	return state, changed, nil
+}
+
+// Status is a breaker as reported by the API and metrics. An open breaker
+// whose cool-down has passed is reported half-open, since the next job will
+// be let through as a probe.
+type Status struct {
+	JobType     models.JOB_TYPE `json:"type"`
+	Destination string          `json:"destination,omitempty"`
+	State       STATE           `json:"state"`
+	Failures    int             `json:"failures"`
+	OpenedAt    *time.Time      `json:"opened_at,omitempty"`
+	OpenUntil   *time.Time      `json:"open_until,omitempty"`
+}
+
+// List returns every breaker that is not closed or has counted failures,
+// ordered by job type and destination.
+func (b *Breakers) List(ctx context.Context) ([]Status, error) {
+	var keys []string
+	iter := b.RedisClient.Scan(ctx, 0, keyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	now := time.Now()
+	for _, k := range keys {
+		fields, err := b.RedisClient.HGetAll(ctx, k).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		jobType, destination, _ := strings.Cut(strings.TrimPrefix(k, keyPrefix), ":")
+		status := Status{
+			JobType:     models.JOB_TYPE(jobType),
+			Destination: destination,
+			State:       STATE(fields["state"]),
+		}
+		status.Failures, _ = strconv.Atoi(fields["failures"])
+		status.OpenedAt = millis(fields["opened_at"])
+		if status.State == STATE_OPEN {
+			status.OpenUntil = millis(fields["open_until"])
+			if status.OpenUntil != nil && !now.Before(*status.OpenUntil) {
+				status.State = STATE_HALF_OPEN
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].JobType != statuses[j].JobType {
+			return statuses[i].JobType < statuses[j].JobType
+		}
+		return statuses[i].Destination < statuses[j].Destination
+	})
+	return statuses, nil
+}
+
+func millis(value string) *time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	t := time.UnixMilli(ms)
+	return &t
+}
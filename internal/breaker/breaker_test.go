@@ -0,0 +1,190 @@
+package breaker
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+const (
+	testThreshold    = 3
+	testOpenDuration = 30 * time.Second
+	testProbeTimeout = 2 * time.Minute
+)
+
+func TestRecord(t *testing.T) {
+	const now = int64(1_700_000_000_000)
+	tests := []struct {
+		name        string
+		current     breakerState
+		success     bool
+		want        breakerState
+		wantChanged bool
+	}{
+		{
+			name:    "closed failure below threshold",
+			current: breakerState{State: STATE_CLOSED},
+			want:    breakerState{State: STATE_CLOSED, Failures: 1},
+		},
+		{
+			name:    "closed failure just below threshold",
+			current: breakerState{State: STATE_CLOSED, Failures: testThreshold - 2},
+			want:    breakerState{State: STATE_CLOSED, Failures: testThreshold - 1},
+		},
+		{
+			name:        "closed failure reaching threshold",
+			current:     breakerState{State: STATE_CLOSED, Failures: testThreshold - 1},
+			want:        breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now, OpenUntil: now + testOpenDuration.Milliseconds()},
+			wantChanged: true,
+		},
+		{
+			name:    "closed success resets failures",
+			current: breakerState{State: STATE_CLOSED, Failures: testThreshold - 1},
+			success: true,
+			want:    breakerState{State: STATE_CLOSED},
+		},
+		{
+			name:        "half-open probe succeeds",
+			current:     breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, OpenedAt: now - 1, ProbeUntil: now + 1},
+			success:     true,
+			want:        breakerState{State: STATE_CLOSED},
+			wantChanged: true,
+		},
+		{
+			name:        "half-open probe fails",
+			current:     breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, OpenedAt: now - 1, ProbeUntil: now + 1},
+			want:        breakerState{State: STATE_OPEN, Failures: testThreshold + 1, OpenedAt: now, OpenUntil: now + testOpenDuration.Milliseconds()},
+			wantChanged: true,
+		},
+		{
+			name:    "open ignores late failure",
+			current: breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 1, OpenUntil: now + 1},
+			want:    breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 1, OpenUntil: now + 1},
+		},
+		{
+			name:    "open ignores late success",
+			current: breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 1, OpenUntil: now + 1},
+			success: true,
+			want:    breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 1, OpenUntil: now + 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := tt.current.record(tt.success, now, testThreshold, testOpenDuration)
+			if got != tt.want || changed != tt.wantChanged {
+				t.Errorf("record = %+v, %v; want %+v, %v", got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	const now = int64(1_700_000_000_000)
+	probeUntil := now + testProbeTimeout.Milliseconds()
+	tests := []struct {
+		name        string
+		current     breakerState
+		wantAllowed bool
+		wantRetryAt int64
+		want        breakerState
+	}{
+		{
+			name:        "closed",
+			current:     breakerState{State: STATE_CLOSED},
+			wantAllowed: true,
+			want:        breakerState{State: STATE_CLOSED},
+		},
+		{
+			name:        "closed with failures below threshold",
+			current:     breakerState{State: STATE_CLOSED, Failures: testThreshold - 1},
+			wantAllowed: true,
+			want:        breakerState{State: STATE_CLOSED, Failures: testThreshold - 1},
+		},
+		{
+			name:        "open during cool-down",
+			current:     breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 10, OpenUntil: now + 10},
+			wantRetryAt: now + 10,
+			want:        breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 10, OpenUntil: now + 10},
+		},
+		{
+			name:        "open after cool-down leases a probe",
+			current:     breakerState{State: STATE_OPEN, Failures: testThreshold, OpenedAt: now - 10, OpenUntil: now},
+			wantAllowed: true,
+			want:        breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, OpenedAt: now - 10, OpenUntil: now, ProbeUntil: probeUntil},
+		},
+		{
+			name:        "half-open while probe runs",
+			current:     breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, ProbeUntil: now + 10},
+			wantRetryAt: now + 10,
+			want:        breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, ProbeUntil: now + 10},
+		},
+		{
+			name:        "half-open after lost probe leases another",
+			current:     breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, ProbeUntil: now},
+			wantAllowed: true,
+			want:        breakerState{State: STATE_HALF_OPEN, Failures: testThreshold, ProbeUntil: probeUntil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, retryAt, got := tt.current.allow(now, testProbeTimeout)
+			if allowed != tt.wantAllowed || retryAt != tt.wantRetryAt || got != tt.want {
+				t.Errorf("allow = %v, %d, %+v; want %v, %d, %+v", allowed, retryAt, got, tt.wantAllowed, tt.wantRetryAt, tt.want)
+			}
+		})
+	}
+}
+
+// Failures below the threshold must not keep other jobs out: every job is
+// still admitted until the threshold is reached, and the breaker then
+// admits a single probe once the cool-down has passed.
+func TestSequence(t *testing.T) {
+	now := int64(1_700_000_000_000)
+	s := breakerState{State: STATE_CLOSED}
+	for i := 0; i < testThreshold; i++ {
+		allowed, _, next := s.allow(now, testProbeTimeout)
+		if !allowed {
+			t.Fatalf("job refused after %d failures", i)
+		}
+		s, _ = next.record(false, now, testThreshold, testOpenDuration)
+	}
+	if s.State != STATE_OPEN {
+		t.Fatalf("state = %s after %d failures, want open", s.State, testThreshold)
+	}
+
+	if allowed, _, _ := s.allow(now+1, testProbeTimeout); allowed {
+		t.Fatal("open breaker admitted a job during its cool-down")
+	}
+	now += testOpenDuration.Milliseconds()
+	allowed, _, s := s.allow(now, testProbeTimeout)
+	if !allowed || s.State != STATE_HALF_OPEN {
+		t.Fatalf("allow = %v, %s; want the probe admitted half-open", allowed, s.State)
+	}
+	if allowed, _, _ := s.allow(now, testProbeTimeout); allowed {
+		t.Fatal("half-open breaker admitted a second job while the probe runs")
+	}
+	s, changed := s.record(true, now, testThreshold, testOpenDuration)
+	if s != (breakerState{State: STATE_CLOSED}) || !changed {
+		t.Fatalf("record = %+v, %v; want closed and changed", s, changed)
+	}
+}
+
+func TestStateFieldsRoundTrip(t *testing.T) {
+	states := []breakerState{
+		{State: STATE_CLOSED, Failures: 2},
+		{State: STATE_OPEN, Failures: 10, OpenedAt: 1, OpenUntil: 2},
+		{State: STATE_HALF_OPEN, Failures: 10, OpenedAt: 1, OpenUntil: 2, ProbeUntil: 3},
+	}
+	for _, s := range states {
+		fields := map[string]string{}
+		for name, value := range s.fields() {
+			fields[name] = fmt.Sprint(value)
+		}
+		if got := parseState(fields); got != s {
+			t.Errorf("parseState(fields()) = %+v, want %+v", got, s)
+		}
+	}
+	if got := parseState(map[string]string{}); got != (breakerState{State: STATE_CLOSED}) {
+		t.Errorf("missing breaker parsed as %+v, want closed", got)
+	}
+}
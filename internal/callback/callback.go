@@ -0,0 +1,383 @@
+// Package callback notifies producers when their jobs finish. The worker
+// calls Queue as a job reaches an event its producer subscribed to, which
+// records a pending delivery; Dispatcher sends pending deliveries as signed
+// POST requests and retries them under config.CallbackRetryPolicy.
+//
+// Each request carries the event in X-Job-Event, the delivery ID in
+// X-Job-Delivery and a signature in X-Job-Signature of the form
+// t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">, keyed with
+// config.CallbackSigningSecret. Receivers should reject stale timestamps and
+// may use the delivery ID to drop the duplicates a retry can cause.
+//
+// Callback URLs come from producers, so the dispatcher only connects to
+// public addresses. The check runs on the address actually dialled, after
+// DNS resolution, so a name that resolves (or later rebinds) to an internal
+// address is refused as well; redirects are not followed.
+package callback
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/metrics"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/retry"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	EVENT_HEADER     = "X-Job-Event"
+	DELIVERY_HEADER  = "X-Job-Delivery"
+	SIGNATURE_HEADER = "X-Job-Signature"
+)
+
+type DELIVERY_STATUS string
+
+const (
+	DELIVERY_STATUS_PENDING   DELIVERY_STATUS = "pending"
+	DELIVERY_STATUS_DELIVERED DELIVERY_STATUS = "delivered"
+	DELIVERY_STATUS_FAILED    DELIVERY_STATUS = "failed"
+)
+
+// Events lists every callback event, the default when a submission names
+// none.
+var Events = []models.CALLBACK_EVENT{
+	models.CALLBACK_EVENT_COMPLETED,
+	models.CALLBACK_EVENT_FAILED,
+	models.CALLBACK_EVENT_DEAD_LETTERED,
+}
+
+// Delivery is one notification of one event and how sending it went.
+type Delivery struct {
+	ID            int64           `json:"id"`
+	JobID         int             `json:"job_id"`
+	Event         string          `json:"event"`
+	URL           string          `json:"url"`
+	Body          json.RawMessage `json:"body"`
+	Status        DELIVERY_STATUS `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"`
+	ResponseCode  *int            `json:"response_code,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// Queue records a delivery of event for the job if its producer asked for
+// one. The body is the job's state as of this call.
+func Queue(ctx context.Context, pool *pgxpool.Pool, jobID int, event models.CALLBACK_EVENT) error {
+	_, err := pool.Exec(ctx, `
+		INSERT INTO callback_deliveries (job_id, tenant_id, event, url, body)
+		SELECT id, tenant_id, $2, callback_url, jsonb_build_object(
+			'event', $2::text, 'job_id', id, 'tenant_id', tenant_id, 'type', type, 'status', status,
+			'attempts', attempts, 'last_error', last_error, 'labels', labels, 'progress', progress,
+			'occurred_at', now())
+		FROM jobs WHERE id = $1 AND callback_url IS NOT NULL AND $2 = ANY(callback_events)
+	`, jobID, string(event))
+	return err
+}
+
+// List returns the deliveries recorded for a job, oldest first.
+func List(ctx context.Context, pool *pgxpool.Pool, jobID int) ([]Delivery, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT id, job_id, event, url, body, status, attempts,
+			CASE WHEN status = $2 THEN next_attempt_at END, response_code, COALESCE(last_error, ''),
+			created_at, delivered_at
+		FROM callback_deliveries WHERE job_id = $1 ORDER BY id
+	`, jobID, DELIVERY_STATUS_PENDING)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.JobID, &d.Event, &d.URL, &d.Body, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// Sign returns the X-Job-Signature value for body sent at timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher sends pending deliveries. Any number of workers can run one;
+// a claimed delivery is leased so only one of them sends it at a time.
+type Dispatcher struct {
+	pool     *pgxpool.Pool
+	settings *config.Live
+	client   *http.Client
+	log      *zap.SugaredLogger
+}
+
+func NewDispatcher(pool *pgxpool.Pool, settings *config.Live, log *zap.SugaredLogger) *Dispatcher {
+	dialer := &net.Dialer{Timeout: config.CALLBACK_TIMEOUT, Control: publicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &Dispatcher{
+		pool:     pool,
+		settings: settings,
+		client: &http.Client{
+			Timeout:   config.CALLBACK_TIMEOUT,
+			Transport: transport,
+			// A redirect is reported as the response it is; following it
+			// would let a receiver point the dispatcher anywhere.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		log: log,
+	}
+}
+
+// nonPublicPrefixes are special-purpose ranges that netip has no predicate
+// for: "this network", shared address space (CGNAT), IETF protocol
+// assignments, benchmarking, the IPv4 reserved block and NAT64.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// PublicAddr reports whether callbacks may be sent to addr. Loopback,
+// private, link-local (which includes cloud metadata endpoints), multicast
+// and other special-purpose addresses are refused.
+func PublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckHost rejects callback hosts that are known not to be public without
+// a DNS lookup: localhost names and literal non-public addresses. Names
+// that resolve to such addresses are refused when the callback is sent.
+func CheckHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%s is not a public host", host)
+	}
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !PublicAddr(addr) {
+		return fmt.Errorf("%s is not a public address", host)
+	}
+	return nil
+}
+
+// publicOnly is the dialer's Control hook, which sees the resolved address
+// of every connection attempt.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return retry.Fatal(err)
+	}
+	if !PublicAddr(addrPort.Addr()) {
+		return retry.Fatal(fmt.Errorf("callback address %s is not public", addrPort.Addr()))
+	}
+	return nil
+}
+
+// Run delivers due callbacks until ctx is done. A batch already being sent
+// is finished first, so shutting down does not cost deliveries an attempt.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.CALLBACK_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		// Keep going while full batches come back so a backlog drains
+		// faster than one batch per tick.
+		for ctx.Err() == nil {
+			n, err := d.deliverDue()
+			if err != nil {
+				d.log.Warnf("Failed to claim callback deliveries: %v", err)
+			}
+			if n < config.CALLBACK_BATCH_SIZE {
+				break
+			}
+		}
+	}
+}
+
+type claimed struct {
+	id       int64
+	event    string
+	url      string
+	body     []byte
+	attempts int
+}
+
+func (d *Dispatcher) deliverDue() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// The lease outlasts the request timeout, so a delivery whose worker
+	// died is picked up again without being sent twice concurrently.
+	rows, err := d.pool.Query(ctx, `
+		UPDATE callback_deliveries SET attempts = attempts + 1, next_attempt_at = now() + $3::interval
+		WHERE id IN (
+			SELECT id FROM callback_deliveries
+			WHERE status = $1 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event, url, body, attempts
+	`, DELIVERY_STATUS_PENDING, config.CALLBACK_BATCH_SIZE, 2*config.CALLBACK_TIMEOUT)
+	if err != nil {
+		return 0, err
+	}
+	var batch []claimed
+	for rows.Next() {
+		var c claimed
+		if err := rows.Scan(&c.id, &c.event, &c.url, &c.body, &c.attempts); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, c := range batch {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(c)
+		}()
+	}
+	wg.Wait()
+	return len(batch), nil
+}
+
+func (d *Dispatcher) deliver(c claimed) {
+	settings := d.settings.Get()
+	code, err := d.send(settings.CallbackSigningSecret, c)
+	if err == nil {
+		d.record(c, DELIVERY_STATUS_DELIVERED, code, nil, time.Time{})
+		metrics.CallbackDeliveriesTotal.WithLabelValues(c.event, string(DELIVERY_STATUS_DELIVERED)).Inc()
+		return
+	}
+
+	decision := retry.Decide(settings.CallbackRetryPolicy, c.attempts-1, err)
+	if decision.Retry {
+		d.log.Infow("Callback delivery failed, retrying", "delivery_id", c.id, "attempt", c.attempts,
+			"delay", decision.Delay, "error", err)
+		d.record(c, DELIVERY_STATUS_PENDING, code, err, time.Now().Add(decision.Delay))
+		metrics.CallbackDeliveriesTotal.WithLabelValues(c.event, "retried").Inc()
+		return
+	}
+	d.log.Warnw("Giving up on callback delivery", "delivery_id", c.id, "attempt", c.attempts,
+		"reason", decision.Reason, "error", err)
+	d.record(c, DELIVERY_STATUS_FAILED, code, err, time.Time{})
+	metrics.CallbackDeliveriesTotal.WithLabelValues(c.event, string(DELIVERY_STATUS_FAILED)).Inc()
+}
+
+// send posts the delivery and returns the response code, 0 if there was no
+// response. Redirects and client errors other than 408 and 429 are final,
+// since sending the same request again will not change them; Retry-After
+// is honoured on the errors that are retried.
+func (d *Dispatcher) send(secret string, c claimed) (int, error) {
+	request, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(c.body))
+	if err != nil {
+		return 0, retry.Fatal(err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EVENT_HEADER, c.event)
+	request.Header.Set(DELIVERY_HEADER, strconv.FormatInt(c.id, 10))
+	request.Header.Set(SIGNATURE_HEADER, Sign(secret, time.Now(), c.body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response.StatusCode, nil
+	}
+	err = fmt.Errorf("callback returned %s", response.Status)
+	if permanentStatus(response.StatusCode) {
+		return response.StatusCode, retry.Fatal(err)
+	}
+	if seconds, convErr := strconv.Atoi(response.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		return response.StatusCode, retry.After(time.Duration(seconds)*time.Second, err)
+	}
+	return response.StatusCode, err
+}
+
+func permanentStatus(code int) bool {
+	if code == http.StatusRequestTimeout || code == http.StatusTooManyRequests {
+		return false
+	}
+	return code >= 300 && code < 500
+}
+
+func (d *Dispatcher) record(c claimed, status DELIVERY_STATUS, code int, deliveryErr error, nextAttemptAt time.Time) {
+	var responseCode *int
+	if code != 0 {
+		responseCode = &code
+	}
+	var lastError *string
+	if deliveryErr != nil {
+		msg := deliveryErr.Error()
+		lastError = &msg
+	}
+	var next *time.Time
+	if !nextAttemptAt.IsZero() {
+		next = &nextAttemptAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := d.pool.Exec(ctx, `
+		UPDATE callback_deliveries
+		SET status = $2, response_code = $3, last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+		WHERE id = $1
+	`, c.id, status, responseCode, lastError, next)
+	if err != nil {
+		d.log.Errorf("Failed to record callback delivery %d: %v", c.id, err)
+	}
+}
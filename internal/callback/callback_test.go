@@ -0,0 +1,124 @@
+package callback
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/retry"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00:ec2::254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		wantErr bool
+	}{
+		{"hooks.example.com", false},
+		{"93.184.216.34", false},
+		{"localhost", true},
+		{"LOCALHOST.", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"[::1]", true},
+		{"::1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if err := CheckHost(tt.host); (err != nil) != tt.wantErr {
+				t.Errorf("CheckHost(%q) = %v, want error %v", tt.host, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPublicOnlyRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	d := NewDispatcher(nil, nil, nil)
+	_, err := d.send("secret", claimed{id: 1, event: "completed", url: server.URL, body: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("send to a loopback address succeeded")
+	}
+	if !retry.IsFatal(err) {
+		t.Errorf("send error %v is not fatal", err)
+	}
+}
+
+// The remaining cases talk to the test server on loopback, so they use the
+// dispatcher's redirect handling without its address check.
+func TestSendStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		wantErr   bool
+		wantFatal bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusNoContent, false, false},
+		{http.StatusFound, true, true},
+		{http.StatusBadRequest, true, true},
+		{http.StatusUnauthorized, true, true},
+		{http.StatusNotFound, true, true},
+		{http.StatusGone, true, true},
+		{http.StatusRequestTimeout, true, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusInternalServerError, true, false},
+		{http.StatusServiceUnavailable, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status == http.StatusFound {
+					w.Header().Set("Location", "http://169.254.169.254/latest/meta-data/")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			d := NewDispatcher(nil, nil, nil)
+			d.client.Transport = server.Client().Transport
+			code, err := d.send("secret", claimed{id: 1, event: "completed", url: server.URL, body: []byte(`{}`)})
+			if code != tt.status {
+				t.Errorf("code = %d, want %d", code, tt.status)
+			}
+			if (err != nil) != tt.wantErr || retry.IsFatal(err) != tt.wantFatal {
+				t.Errorf("err = %v, want error %v, fatal %v", err, tt.wantErr, tt.wantFatal)
+			}
+		})
+	}
+}
@@ -4,19 +4,149 @@ import (
 	"context"
 	"time"
 
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/retry"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
 	"go.uber.org/zap"
 )
 
+// Config is shared by cmd/api and cmd/worker. Every field can be set in the
+// YAML file under its yaml key; scalar fields can also be set through the
+// listed environment variable and flag. Fields tagged reload:"true" are
+// picked up on SIGHUP, everything else needs a restart. See Default for the
+// default values and config.example.yaml for a documented sample.
 type Config struct {
-	ServerPort    string
-	RedisAddr     string
-	RedisPassword string
-	PostgresDSN   string
+	ServerPort    string `yaml:"server_port" env:"JOBQUEUE_SERVER_PORT" flag:"server-port" usage:"port the API listens on"`
+	RedisAddr     string `yaml:"redis_addr" env:"JOBQUEUE_REDIS_ADDR" flag:"redis-addr" usage:"Redis host:port"`
+	RedisPassword string `yaml:"redis_password" env:"JOBQUEUE_REDIS_PASSWORD" flag:"redis-password" usage:"Redis password"`
+	PostgresDSN   string `yaml:"postgres_dsn" env:"JOBQUEUE_POSTGRES_DSN" flag:"postgres-dsn" usage:"Postgres connection string"`
+
+	AdminAPIKey string `yaml:"admin_api_key" env:"JOBQUEUE_ADMIN_API_KEY" flag:"admin-api-key" usage:"bootstrap API key with the admin role"`
+	JWKSFile    string `yaml:"jwks_file" env:"JOBQUEUE_JWKS_FILE" flag:"jwks-file" usage:"JWKS file used to verify bearer tokens"`
+	JWTIssuer   string `yaml:"jwt_issuer" env:"JOBQUEUE_JWT_ISSUER" flag:"jwt-issuer" usage:"required iss claim of bearer tokens"`
+	JWTAudience string `yaml:"jwt_audience" env:"JOBQUEUE_JWT_AUDIENCE" flag:"jwt-audience" usage:"required aud claim of bearer tokens"`
+
+	MasterKeyFile string `yaml:"master_key_file" env:"JOBQUEUE_MASTER_KEY_FILE" flag:"master-key-file" usage:"file of payload master keys, one 'id base64-key' per line, newest first"`
+	MasterKeys    string `yaml:"master_keys" env:"JOBQUEUE_MASTER_KEYS" flag:"master-keys" usage:"payload master keys as id:base64-key,..., newest first"`
+
+	CallbackSigningSecret string `yaml:"callback_signing_secret" env:"JOBQUEUE_CALLBACK_SIGNING_SECRET" flag:"callback-signing-secret" usage:"HMAC-SHA256 key for job callbacks; empty disables callbacks"`
+
+	TraceExporter string `yaml:"trace_exporter" env:"JOBQUEUE_TRACE_EXPORTER" flag:"trace-exporter" usage:"none, stdout, file or otlp"`
+	TraceFile     string `yaml:"trace_file" env:"JOBQUEUE_TRACE_FILE" flag:"trace-file" usage:"destination of the file trace exporter"`
+
+	LogLevel string `yaml:"log_level" env:"JOBQUEUE_LOG_LEVEL" flag:"log-level" usage:"debug, info, warn or error" reload:"true"`
+
+	HighPriorityPollingInterval   time.Duration `yaml:"high_priority_polling_interval" env:"JOBQUEUE_HIGH_PRIORITY_POLLING_INTERVAL" flag:"high-priority-polling-interval" usage:"how often the HIGH queue is polled" reload:"true"`
+	MediumPriorityPollingInterval time.Duration `yaml:"medium_priority_polling_interval" env:"JOBQUEUE_MEDIUM_PRIORITY_POLLING_INTERVAL" flag:"medium-priority-polling-interval" usage:"how often the MEDIUM queue is polled" reload:"true"`
+	LowPriorityPollingInterval    time.Duration `yaml:"low_priority_polling_interval" env:"JOBQUEUE_LOW_PRIORITY_POLLING_INTERVAL" flag:"low-priority-polling-interval" usage:"how often the LOW queue is polled" reload:"true"`
+	MaxRetries                    int           `yaml:"max_retries" env:"JOBQUEUE_MAX_RETRIES" flag:"max-retries" usage:"default retries before a job is given up on" reload:"true"`
+	BaseBackoffSec                int           `yaml:"base_backoff_sec" env:"JOBQUEUE_BASE_BACKOFF_SEC" flag:"base-backoff-sec" usage:"default base of the retry backoff, in seconds" reload:"true"`
+	WorkerPoolSize                int           `yaml:"worker_pool_size" env:"JOBQUEUE_WORKER_POOL_SIZE" flag:"worker-pool-size" usage:"concurrent job executions per worker"`
+	WorkerMetricsPort             string        `yaml:"worker_metrics_port" env:"JOBQUEUE_WORKER_METRICS_PORT" flag:"worker-metrics-port" usage:"port the worker serves /metrics on"`
+	ShutdownDrainTimeout          time.Duration `yaml:"shutdown_drain_timeout" env:"JOBQUEUE_SHUTDOWN_DRAIN_TIMEOUT" flag:"shutdown-drain-timeout" usage:"how long running jobs may finish on shutdown before they are put back in the queue" reload:"true"`
+
+	LifecycleStreamMaxLen int           `yaml:"lifecycle_stream_max_len" env:"JOBQUEUE_LIFECYCLE_STREAM_MAX_LEN" flag:"lifecycle-stream-max-len" usage:"entries kept in the lifecycle event stream, 0 for no limit" reload:"true"`
+	LifecycleStreamMaxAge time.Duration `yaml:"lifecycle_stream_max_age" env:"JOBQUEUE_LIFECYCLE_STREAM_MAX_AGE" flag:"lifecycle-stream-max-age" usage:"age after which lifecycle events are trimmed, 0 for no limit" reload:"true"`
+
+	DefaultTenantQuota TenantQuota            `yaml:"default_tenant_quota" reload:"true"`
+	TenantQuotas       map[string]TenantQuota `yaml:"tenant_quotas" reload:"true"`
+
+	DefaultRetryPolicy models.RetryPolicy                     `yaml:"default_retry_policy" reload:"true"`
+	RetryPolicies      map[models.JOB_TYPE]models.RetryPolicy `yaml:"retry_policies" reload:"true"`
+	// MaxJobRetryAttempts and MinJobRetryDelaySec bound the retry override a
+	// submission may carry, so producers cannot ask for endless or tight
+	// retry loops. Configured policies are not bound by them.
+	MaxJobRetryAttempts int `yaml:"max_job_retry_attempts" env:"JOBQUEUE_MAX_JOB_RETRY_ATTEMPTS" flag:"max-job-retry-attempts" usage:"most attempts a submission's retry override may ask for" reload:"true"`
+	MinJobRetryDelaySec int `yaml:"min_job_retry_delay_sec" env:"JOBQUEUE_MIN_JOB_RETRY_DELAY_SEC" flag:"min-job-retry-delay-sec" usage:"shortest base_delay_sec and max_delay_sec a submission's retry override may ask for" reload:"true"`
+
+	DefaultCircuitBreaker CircuitBreaker                     `yaml:"default_circuit_breaker" reload:"true"`
+	CircuitBreakers       map[models.JOB_TYPE]CircuitBreaker `yaml:"circuit_breakers" reload:"true"`
+
+	// CallbackRetryPolicy governs callback deliveries, independently of the
+	// policy of the job being reported on.
+	CallbackRetryPolicy models.RetryPolicy `yaml:"callback_retry_policy" reload:"true"`
+}
+
+// CircuitBreaker configures the breakers of one job type. A breaker opens
+// after FailureThreshold consecutive failures, stays open for OpenDuration
+// and then lets a single probe job through; ProbeTimeout bounds how long
+// that probe may hold the breaker before another one is allowed. A zero
+// FailureThreshold disables the breaker.
+type CircuitBreaker struct {
+	FailureThreshold int           `yaml:"failure_threshold"`
+	OpenDuration     time.Duration `yaml:"open_duration"`
+	ProbeTimeout     time.Duration `yaml:"probe_timeout"`
+}
+
+// TenantQuota bounds how much of the shared queue a single tenant may use.
+// A zero value for any field disables that particular limit.
+type TenantQuota struct {
+	MaxQueuedJobs        int `yaml:"max_queued_jobs"`
+	SubmissionsPerMinute int `yaml:"submissions_per_minute"`
+	MaxPayloadBytes      int `yaml:"max_payload_bytes"`
+}
+
+// QuotaForTenant returns the tenant's override if one is configured and the
+// default quota otherwise.
+func (c Config) QuotaForTenant(tenantID string) TenantQuota {
+	if quota, ok := c.TenantQuotas[tenantID]; ok {
+		return quota
+	}
+	return c.DefaultTenantQuota
+}
+
+// CircuitBreakerForType returns the type's override if one is configured and
+// the default breaker settings otherwise.
+func (c Config) CircuitBreakerForType(jobType models.JOB_TYPE) CircuitBreaker {
+	if breaker, ok := c.CircuitBreakers[jobType]; ok {
+		return breaker
+	}
+	return c.DefaultCircuitBreaker
+}
+
+// RetryPolicy returns the policy for jobs of jobType: max_retries and
+// base_backoff_sec, then default_retry_policy, then the type's entry in
+// retry_policies, each overriding the fields it sets.
+func (c Config) RetryPolicy(jobType models.JOB_TYPE) models.RetryPolicy {
+	policy := models.RetryPolicy{
+		MaxAttempts:  c.MaxRetries + 1,
+		Strategy:     models.RETRY_STRATEGY_EXPONENTIAL,
+		BaseDelaySec: c.BaseBackoffSec,
+	}
+	policy = retry.Override(policy, c.DefaultRetryPolicy)
+	return retry.Override(policy, c.RetryPolicies[jobType])
+}
+
+// JobRetryPolicy returns the policy for a job of jobType with the
+// submission's override, if any, applied within max_job_retry_attempts and
+// min_job_retry_delay_sec.
+func (c Config) JobRetryPolicy(jobType models.JOB_TYPE, override *models.RetryPolicy) models.RetryPolicy {
+	policy := c.RetryPolicy(jobType)
+	if override == nil {
+		return policy
+	}
+	return retry.Override(policy, retry.Bound(*override, c.MaxJobRetryAttempts, c.MinJobRetryDelaySec))
+}
+
+// PollingInterval returns how often the worker polls the queue for priority.
+func (c Config) PollingInterval(priority models.JOB_PRIORITY) time.Duration {
+	switch priority {
+	case models.JOB_PRIORITY_HIGH:
+		return c.HighPriorityPollingInterval
+	case models.JOB_PRIORITY_MEDIUM:
+		return c.MediumPriorityPollingInterval
+	default:
+		return c.LowPriorityPollingInterval
+	}
 }
 
 type ctxKey string
 
-const LoggerKey ctxKey = "logger"
+const (
+	LoggerKey    ctxKey = "logger"
+	TenantKey    ctxKey = "tenant"
+	PrincipalKey ctxKey = "principal"
+	RequestIDKey ctxKey = "request_id"
+)
 
 func LoggerFromContext(ctx context.Context) *zap.Logger {
 	val := ctx.Value(LoggerKey)
@@ -26,6 +156,37 @@ func LoggerFromContext(ctx context.Context) *zap.Logger {
 	return zap.NewNop()
 }
 
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDKey).(string)
+	return requestID
+}
+
+func TenantFromContext(ctx context.Context) string {
+	val := ctx.Value(TenantKey)
+	if tenantID, ok := val.(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DEFAULT_TENANT
+}
+
+const DEFAULT_TENANT = "default"
+
+const (
+	API_KEY_HEADER       = "X-API-Key"
+	AUTHORIZATION_HEADER = "Authorization"
+)
+
+const (
+	DEFAULT_MAX_QUEUED_JOBS        = 10000
+	DEFAULT_SUBMISSIONS_PER_MINUTE = 600
+	DEFAULT_MAX_PAYLOAD_BYTES      = 64 * 1024
+)
+
+// SUBMISSION_BODY_OVERHEAD_BYTES is how far a submission body may exceed the
+// tenant's payload limit, leaving room for labels, the callback URL and the
+// other fields around the payload.
+const SUBMISSION_BODY_OVERHEAD_BYTES = 16 * 1024
+
 const (
 	HIGH_PRIORITY_POLLING_INTERVAL   time.Duration = 3 * time.Second
 	MEDIUM_PRIORITY_POLLING_INTERVAL time.Duration = 30 * time.Second
@@ -34,6 +195,77 @@ const (
 
 const BATCH_SIZE = 10000
 
+const (
+	DEFAULT_LIST_LIMIT    = 100
+	MAX_LIST_LIMIT        = 1000
+	MAX_JOB_LABELS        = 16
+	MAX_LABEL_KEY_BYTES   = 63
+	MAX_LABEL_VALUE_BYTES = 255
+	MAX_JOB_DELAY_SECONDS = 30 * 24 * 60 * 60
+)
+
+// Limits on payload and text filters in job listings, so every accepted
+// query can use the GIN indexes.
+const (
+	MAX_PAYLOAD_FILTERS      = 5
+	MAX_PAYLOAD_FILTER_DEPTH = 4
+	MAX_FILTER_VALUE_BYTES   = 256
+	MIN_CONTAINS_SEARCH_LEN  = 3
+)
+
+const (
+	DEFAULT_MAX_RETRIES             = 5
+	DEFAULT_BASE_BACKOFF_SEC        = 5
+	DEFAULT_WORKER_POOL_SIZE        = 50
+	DEFAULT_WORKER_METRICS_PORT     = "9100"
+	DEFAULT_SHUTDOWN_DRAIN_TIMEOUT  = 30 * time.Second
+	DEFAULT_MAX_RETRY_DELAY_SEC     = 60 * 60
+	DEFAULT_RETRY_JITTER            = 0.2
+	DEFAULT_MAX_JOB_RETRY_ATTEMPTS  = 25
+	DEFAULT_MIN_JOB_RETRY_DELAY_SEC = 1
+)
+
+const (
+	DEFAULT_BREAKER_FAILURE_THRESHOLD               = 10
+	DEFAULT_BREAKER_OPEN_DURATION     time.Duration = 30 * time.Second
+	DEFAULT_BREAKER_PROBE_TIMEOUT     time.Duration = 2 * time.Minute
+)
+
+const (
+	JOB_LOG_MAX_BYTES_PER_ATTEMPT               = 32 * 1024
+	JOB_LOG_MAX_LINES                           = 500
+	JOB_LOG_TTL                   time.Duration = 7 * 24 * time.Hour
+)
+
+const (
+	CALLBACK_POLL_INTERVAL          time.Duration = time.Second
+	CALLBACK_BATCH_SIZE                           = 20
+	CALLBACK_TIMEOUT                time.Duration = 10 * time.Second
+	MAX_CALLBACK_URL_BYTES                        = 2048
+	DEFAULT_CALLBACK_MAX_ATTEMPTS                 = 10
+	DEFAULT_CALLBACK_BASE_DELAY_SEC               = 10
+)
+
+const (
+	LIFECYCLE_STREAM_KEY                           = "job_lifecycle"
+	LIFECYCLE_STREAM_TRIM_INTERVAL   time.Duration = time.Minute
+	DEFAULT_LIFECYCLE_STREAM_MAX_LEN               = 1000000
+	DEFAULT_LIFECYCLE_STREAM_MAX_AGE time.Duration = 7 * 24 * time.Hour
+)
+
+const (
+	JOB_EVENT_RETENTION      time.Duration = 24 * time.Hour
+	EVENT_KEEPALIVE_INTERVAL time.Duration = 15 * time.Second
+	EVENT_REPLAY_PAGE_SIZE                 = 500
+	EVENT_SUBSCRIBER_BUFFER                = 256
+)
+
+const (
+	PROGRESS_UPDATE_INTERVAL   time.Duration = 2 * time.Second
+	MAX_PROGRESS_STAGE_BYTES                 = 64
+	MAX_PROGRESS_MESSAGE_BYTES               = 256
+)
+
 const (
 	EMAIL_SUCCESS_CHANCE   = 60
 	MESSAGE_SUCCESS_CHANCE = 80
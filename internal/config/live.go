@@ -0,0 +1,75 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+)
+
+// Live is a Config that can be swapped at runtime. Readers call Get on every
+// use instead of holding on to a copy.
+type Live struct {
+	current atomic.Pointer[Config]
+}
+
+func NewLive(cfg Config) *Live {
+	live := &Live{}
+	live.current.Store(&cfg)
+	return live
+}
+
+func (l *Live) Get() Config {
+	return *l.current.Load()
+}
+
+// Reload copies the fields tagged reload:"true" from next into the live
+// config. It returns the yaml keys of other fields that differ, which only
+// take effect after a restart.
+func (l *Live) Reload(next Config) (ignored []string) {
+	updated := l.Get()
+	current := reflect.ValueOf(&updated).Elem()
+	incoming := reflect.ValueOf(next)
+	t := current.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("reload") == "true" {
+			current.Field(i).Set(incoming.Field(i))
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), incoming.Field(i).Interface()) {
+			ignored = append(ignored, t.Field(i).Tag.Get("yaml"))
+		}
+	}
+
+	l.current.Store(&updated)
+	return ignored
+}
+
+// WatchReload calls Load with the original arguments on every SIGHUP until
+// ctx is done, and passes the result to apply. A configuration that fails to
+// load or validate is handed to onError and the live settings are left as
+// they were.
+func WatchReload(ctx context.Context, name string, args []string, apply func(Config), onError func(error)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				next, _, err := Load(name, args)
+				if err != nil {
+					onError(err)
+					continue
+				}
+				apply(next)
+			}
+		}
+	}()
+}
@@ -0,0 +1,304 @@
+package dashboard
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/jobops"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/queue"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+//go:embed static
+var staticFiles embed.FS
+
+var statusOptions = []models.JOB_STATUS{
+	models.JOB_STATUS_QUEUED,
+	models.JOB_STATUS_PROGRESS,
+	models.JOB_STATUS_COMPLETED,
+	models.JOB_STATUS_FAILED,
+	models.JOB_STATUS_DEAD_LETTER,
+	models.JOB_STATUS_CANCELLED,
+}
+
+var typeOptions = []models.JOB_TYPE{
+	models.JOB_TYPE_EMAIL,
+	models.JOB_TYPE_MESSAGE,
+	models.JOB_TYPE_WEBHOOK,
+}
+
+// Dashboard serves the server-rendered admin pages under /admin. Callers see
+// and act on their own tenant's jobs; only a platform admin sees every
+// tenant, including the shared Redis queues.
+type Dashboard struct {
+	PostgresPool *pgxpool.Pool
+	RedisClient  *redis.Client
+	Keyring      *envelope.Keyring
+	pages        map[string]*template.Template
+}
+
+func New(postgresPool *pgxpool.Pool, redisClient *redis.Client, keyring *envelope.Keyring) (*Dashboard, error) {
+	pages := map[string]*template.Template{}
+	for _, page := range []string{"overview", "jobs", "job"} {
+		tmpl, err := template.ParseFS(templateFiles, "templates/layout.html", "templates/"+page+".html")
+		if err != nil {
+			return nil, fmt.Errorf("parse %s template: %w", page, err)
+		}
+		pages[page] = tmpl
+	}
+	return &Dashboard{PostgresPool: postgresPool, RedisClient: redisClient, Keyring: keyring, pages: pages}, nil
+}
+
+func StaticHandler() http.Handler {
+	static, _ := fs.Sub(staticFiles, "static")
+	return http.StripPrefix("/admin/static/", http.FileServer(http.FS(static)))
+}
+
+type page struct {
+	Title string
+	Flash string
+}
+
+func (d *Dashboard) render(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := d.pages[name].ExecuteTemplate(w, "layout", data); err != nil {
+		config.LoggerFromContext(r.Context()).Sugar().Errorf("Failed to render %s page: %v", name, err)
+	}
+}
+
+func (d *Dashboard) serverError(w http.ResponseWriter, r *http.Request, err error) {
+	config.LoggerFromContext(r.Context()).Sugar().Errorf("Dashboard request failed: %v", err)
+	http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+}
+
+type priorityRow struct {
+	Priority models.JOB_PRIORITY
+	Depth    jobops.QueueDepth
+}
+
+type statusRow struct {
+	Status models.JOB_STATUS
+	Count  int
+}
+
+// tenantScope returns the tenant whose jobs the caller may see and act on:
+// its own, or every tenant ("") for a platform admin.
+func tenantScope(r *http.Request) string {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.IsPlatformAdmin() {
+		return ""
+	}
+	return config.TenantFromContext(r.Context())
+}
+
+func (d *Dashboard) Overview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenantID := tenantScope(r)
+
+	counts, err := jobops.StatusCounts(ctx, d.PostgresPool, tenantID)
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+
+	data := struct {
+		page
+		Priorities []priorityRow
+		Statuses   []statusRow
+	}{page: page{Title: "Overview", Flash: r.URL.Query().Get("flash")}}
+	// The queues hold every tenant's jobs, so only a platform admin sees
+	// their depths.
+	if tenantID == "" {
+		depths, err := jobops.QueueDepths(ctx, d.RedisClient)
+		if err != nil {
+			d.serverError(w, r, err)
+			return
+		}
+		for _, priority := range queue.Priorities {
+			data.Priorities = append(data.Priorities, priorityRow{Priority: priority, Depth: depths[priority]})
+		}
+	}
+	for _, status := range statusOptions {
+		data.Statuses = append(data.Statuses, statusRow{Status: status, Count: counts[string(status)]})
+	}
+	d.render(w, r, "overview", data)
+}
+
+// queueEntry is where a job currently sits in Redis, if anywhere.
+type queueEntry struct {
+	Found    bool
+	Priority models.JOB_PRIORITY
+	DueAt    time.Time
+}
+
+type jobsPage struct {
+	page
+	DeadLetters   bool
+	Filter        jobops.Filter
+	StatusOptions []models.JOB_STATUS
+	TypeOptions   []models.JOB_TYPE
+	Jobs          []models.Job
+}
+
+func (d *Dashboard) Jobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := jobops.Filter{
+		TenantID: tenantScope(r),
+		Status:   models.JOB_STATUS(query.Get("status")),
+		Type:     models.JOB_TYPE(query.Get("type")),
+		Limit:    200,
+	}
+
+	jobs, err := jobops.Recent(r.Context(), d.PostgresPool, filter)
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+	d.render(w, r, "jobs", jobsPage{
+		page:          page{Title: "Recent jobs", Flash: query.Get("flash")},
+		Filter:        filter,
+		StatusOptions: statusOptions,
+		TypeOptions:   typeOptions,
+		Jobs:          jobs,
+	})
+}
+
+func (d *Dashboard) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	filter := jobops.Filter{TenantID: tenantScope(r), Status: models.JOB_STATUS_DEAD_LETTER, Limit: 500}
+	jobs, err := jobops.Recent(r.Context(), d.PostgresPool, filter)
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+	d.render(w, r, "jobs", jobsPage{
+		page:        page{Title: "Dead letters", Flash: r.URL.Query().Get("flash")},
+		DeadLetters: true,
+		Filter:      filter,
+		Jobs:        jobs,
+	})
+}
+
+func (d *Dashboard) Job(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	jobID, err := strconv.Atoi(mux.Vars(r)["job_id"])
+	if err != nil {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	job, err := jobops.Get(ctx, d.PostgresPool, tenantScope(r), jobID)
+	if errors.Is(err, jobops.ErrJobNotFound) {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+	if err := jobops.Reveal(d.Keyring, &job); err != nil {
+		config.LoggerFromContext(ctx).Sugar().Warnf("Failed to decrypt payload of job %d: %v", jobID, err)
+		jobops.Redact(&job)
+	}
+	attempts, err := jobops.Attempts(ctx, d.PostgresPool, jobID)
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+	entry := queueEntry{}
+	entry.Priority, entry.DueAt, entry.Found, err = jobops.QueuedAt(ctx, d.RedisClient, jobID)
+	if err != nil {
+		d.serverError(w, r, err)
+		return
+	}
+
+	d.render(w, r, "job", struct {
+		page
+		Job      models.Job
+		Queue    queueEntry
+		Attempts []models.JobAttempt
+	}{
+		page:     page{Title: fmt.Sprintf("Job %d", jobID), Flash: r.URL.Query().Get("flash")},
+		Job:      job,
+		Queue:    entry,
+		Attempts: attempts,
+	})
+}
+
+// Retry, Cancel and Replay are form targets. They redirect back to the job
+// page with a flash message so a browser refresh never repeats the action.
+func (d *Dashboard) Retry(w http.ResponseWriter, r *http.Request) {
+	d.action(w, r, func(jobID int) (string, error) {
+		err := jobops.Retry(r.Context(), d.PostgresPool, d.RedisClient, tenantScope(r), jobID)
+		return fmt.Sprintf("Job %d requeued.", jobID), err
+	})
+}
+
+func (d *Dashboard) Cancel(w http.ResponseWriter, r *http.Request) {
+	d.action(w, r, func(jobID int) (string, error) {
+		err := jobops.Cancel(r.Context(), d.PostgresPool, d.RedisClient, tenantScope(r), jobID)
+		return fmt.Sprintf("Job %d cancelled.", jobID), err
+	})
+}
+
+func (d *Dashboard) Replay(w http.ResponseWriter, r *http.Request) {
+	d.action(w, r, func(jobID int) (string, error) {
+		newID, err := jobops.Replay(r.Context(), d.PostgresPool, d.RedisClient, tenantScope(r), jobID)
+		return fmt.Sprintf("Job %d replayed as job %d.", jobID, newID), err
+	})
+}
+
+func (d *Dashboard) action(w http.ResponseWriter, r *http.Request, run func(jobID int) (string, error)) {
+	if !sameOrigin(r) {
+		http.Error(w, "Cross-origin form submission rejected", http.StatusForbidden)
+		return
+	}
+	jobID, err := strconv.Atoi(mux.Vars(r)["job_id"])
+	if err != nil {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	flash, err := run(jobID)
+	switch {
+	case errors.Is(err, jobops.ErrJobNotFound):
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	case errors.Is(err, jobops.ErrInvalidState):
+		flash = err.Error()
+	case err != nil:
+		d.serverError(w, r, err)
+		return
+	}
+
+	target := fmt.Sprintf("/admin/jobs/%d?flash=%s", jobID, url.QueryEscape(flash))
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// sameOrigin guards the POST actions against cross-site form submissions,
+// which browsers would otherwise send with cached Basic credentials.
+func sameOrigin(r *http.Request) bool {
+	source := r.Header.Get("Origin")
+	if source == "" {
+		source = r.Header.Get("Referer")
+	}
+	if source == "" {
+		return false
+	}
+	parsed, err := url.Parse(source)
+	return err == nil && parsed.Host == r.Host
+}
@@ -0,0 +1,32 @@
+package dashboard
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/auth"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+)
+
+func TestTenantScope(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		want      string
+	}{
+		{"tenant admin key", &auth.Principal{TenantID: "tenant-a", Method: auth.AUTH_METHOD_API_KEY, Roles: []auth.ROLE{auth.ROLE_ADMIN}}, "tenant-a"},
+		{"tenant admin jwt", &auth.Principal{TenantID: "tenant-b", Method: auth.AUTH_METHOD_JWT, Roles: []auth.ROLE{auth.ROLE_ADMIN}}, "tenant-b"},
+		{"bootstrap admin", &auth.Principal{TenantID: config.DEFAULT_TENANT, Method: auth.AUTH_METHOD_ADMIN_KEY, Roles: []auth.ROLE{auth.ROLE_ADMIN}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/admin/jobs", nil)
+			ctx := auth.WithPrincipal(r.Context(), tt.principal)
+			ctx = context.WithValue(ctx, config.TenantKey, tt.principal.TenantID)
+			if got := tenantScope(r.WithContext(ctx)); got != tt.want {
+				t.Errorf("tenantScope = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
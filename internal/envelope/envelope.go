@@ -0,0 +1,194 @@
+// Package envelope encrypts job payloads at rest. Each payload is sealed
+// with its own AES-256-GCM data key, and that data key is stored wrapped
+// (also AES-256-GCM) by a master key from the Keyring. Rotating the master
+// key only rewraps data keys; payload ciphertext never has to change.
+package envelope
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("payload was encrypted with a master key that is not configured")
+
+// Keyring holds the master keys. The primary key wraps new data keys; the
+// others are only used to unwrap existing ones until they are re-encrypted.
+type Keyring struct {
+	primary string
+	keys    map[string]cipher.AEAD
+}
+
+// LoadKeyring reads master keys from file and from spec, in that order, and
+// returns nil when neither is set, which leaves payloads unencrypted. Both
+// use "id base64-key" entries, newest first: one per line in the file,
+// comma-separated in spec with ':' or a space between id and key.
+func LoadKeyring(file, spec string) (*Keyring, error) {
+	var entries []string
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("open master key file: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("read master key file: %w", err)
+		}
+	}
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	ring := &Keyring{keys: map[string]cipher.AEAD{}}
+	for _, entry := range entries {
+		id, encoded, found := strings.Cut(strings.Replace(entry, ":", " ", 1), " ")
+		encoded = strings.TrimSpace(encoded)
+		if !found || id == "" || encoded == "" {
+			return nil, fmt.Errorf("master key entry %q: want 'id base64-key'", redactEntry(entry))
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("master key %q must be %d base64-encoded bytes", id, keySize)
+		}
+		if _, exists := ring.keys[id]; exists {
+			return nil, fmt.Errorf("master key %q is listed twice", id)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		ring.keys[id] = aead
+		if ring.primary == "" {
+			ring.primary = id
+		}
+	}
+	return ring, nil
+}
+
+func redactEntry(entry string) string {
+	id, _, _ := strings.Cut(strings.Replace(entry, ":", " ", 1), " ")
+	return id + " ..."
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// PrimaryID is the ID of the master key that wraps new data keys.
+func (k *Keyring) PrimaryID() string {
+	return k.primary
+}
+
+// Seal encrypts plaintext under a fresh data key. aad binds the ciphertext
+// to its context; Open must be given the same value.
+func (k *Keyring) Seal(plaintext, aad []byte) (models.EncryptedPayload, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return models.EncryptedPayload{}, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return models.EncryptedPayload{}, err
+	}
+	ciphertext, err := seal(aead, plaintext, aad)
+	if err != nil {
+		return models.EncryptedPayload{}, err
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return models.EncryptedPayload{}, err
+	}
+	return models.EncryptedPayload{KeyID: k.primary, WrappedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+// Open decrypts a payload sealed by Seal.
+func (k *Keyring) Open(payload models.EncryptedPayload, aad []byte) ([]byte, error) {
+	dataKey, err := k.unwrap(payload)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, payload.Ciphertext, aad)
+}
+
+// Rewrap re-encrypts the payload's data key under the primary master key.
+// It reports false when the payload already uses the primary key.
+func (k *Keyring) Rewrap(payload models.EncryptedPayload) (models.EncryptedPayload, bool, error) {
+	if payload.KeyID == k.primary {
+		return payload, false, nil
+	}
+	dataKey, err := k.unwrap(payload)
+	if err != nil {
+		return payload, false, err
+	}
+	wrapped, err := seal(k.keys[k.primary], dataKey, []byte(k.primary))
+	if err != nil {
+		return payload, false, err
+	}
+	payload.KeyID, payload.WrappedKey = k.primary, wrapped
+	return payload, true, nil
+}
+
+func (k *Keyring) unwrap(payload models.EncryptedPayload) ([]byte, error) {
+	master, ok := k.keys[payload.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, payload.KeyID)
+	}
+	dataKey, err := open(master, payload.WrappedKey, []byte(payload.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return dataKey, nil
+}
+
+// seal returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+// PayloadAAD binds a job payload's ciphertext to its tenant so a row cannot
+// be moved to another tenant and still decrypt.
+func PayloadAAD(tenantID string) []byte {
+	return []byte("jobs.payload:" + tenantID)
+}
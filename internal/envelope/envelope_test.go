@@ -0,0 +1,165 @@
+package envelope
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func loadKeyring(t *testing.T, spec string) *Keyring {
+	t.Helper()
+	ring, err := LoadKeyring("", spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ring
+}
+
+func flipByte(b []byte, i int) []byte {
+	out := bytes.Clone(b)
+	out[i] ^= 0x01
+	return out
+}
+
+func TestSealOpen(t *testing.T) {
+	ring := loadKeyring(t, "k1:"+newKey(t))
+	plaintext := []byte(`{"to":"user@example.com"}`)
+	sealed, err := ring.Seal(plaintext, PayloadAAD("tenant-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sealed.KeyID != "k1" || bytes.Contains(sealed.Ciphertext, plaintext) {
+		t.Fatalf("sealed = %+v", sealed)
+	}
+	got, err := ring.Open(sealed, PayloadAAD("tenant-a"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("Open = %s, want %s", got, plaintext)
+	}
+}
+
+func TestOpenFailures(t *testing.T) {
+	k1 := newKey(t)
+	ring := loadKeyring(t, "k1:"+k1+",k2:"+newKey(t))
+	sealed, err := ring.Seal([]byte(`{"to":"user@example.com"}`), PayloadAAD("tenant-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		ring        *Keyring
+		payload     func() models.EncryptedPayload
+		aad         []byte
+		wantUnknown bool
+	}{
+		{
+			name:        "master key not configured",
+			ring:        loadKeyring(t, "k2:"+newKey(t)),
+			payload:     func() models.EncryptedPayload { return sealed },
+			aad:         PayloadAAD("tenant-a"),
+			wantUnknown: true,
+		},
+		{
+			name:    "different master key under the same id",
+			ring:    loadKeyring(t, "k1:"+newKey(t)),
+			payload: func() models.EncryptedPayload { return sealed },
+			aad:     PayloadAAD("tenant-a"),
+		},
+		{
+			name: "tampered wrapped key",
+			ring: ring,
+			payload: func() models.EncryptedPayload {
+				p := sealed
+				p.WrappedKey = flipByte(p.WrappedKey, len(p.WrappedKey)-1)
+				return p
+			},
+			aad: PayloadAAD("tenant-a"),
+		},
+		{
+			name: "truncated wrapped key",
+			ring: ring,
+			payload: func() models.EncryptedPayload {
+				p := sealed
+				p.WrappedKey = p.WrappedKey[:4]
+				return p
+			},
+			aad: PayloadAAD("tenant-a"),
+		},
+		{
+			name: "wrapped key relabelled with another master key",
+			ring: ring,
+			payload: func() models.EncryptedPayload {
+				p := sealed
+				p.KeyID = "k2"
+				return p
+			},
+			aad: PayloadAAD("tenant-a"),
+		},
+		{
+			name: "tampered ciphertext",
+			ring: ring,
+			payload: func() models.EncryptedPayload {
+				p := sealed
+				p.Ciphertext = flipByte(p.Ciphertext, len(p.Ciphertext)-1)
+				return p
+			},
+			aad: PayloadAAD("tenant-a"),
+		},
+		{
+			name:    "moved to another tenant",
+			ring:    ring,
+			payload: func() models.EncryptedPayload { return sealed },
+			aad:     PayloadAAD("tenant-b"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plaintext, err := tt.ring.Open(tt.payload(), tt.aad)
+			if err == nil {
+				t.Fatalf("Open = %s, want an error", plaintext)
+			}
+			if errors.Is(err, ErrUnknownKey) != tt.wantUnknown {
+				t.Errorf("err = %v, ErrUnknownKey = %v", err, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old := "k1:" + newKey(t)
+	sealed, err := loadKeyring(t, old).Seal([]byte("payload"), PayloadAAD("tenant-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	primary := "k2:" + newKey(t)
+	rotated := loadKeyring(t, primary+","+old)
+	rewrapped, changed, err := rotated.Rewrap(sealed)
+	if err != nil || !changed || rewrapped.KeyID != "k2" {
+		t.Fatalf("Rewrap = %+v, %v, %v", rewrapped, changed, err)
+	}
+	if _, changed, _ := rotated.Rewrap(rewrapped); changed {
+		t.Error("Rewrap changed a payload already on the primary key")
+	}
+	// The old key can be dropped once every payload is rewrapped.
+	got, err := loadKeyring(t, primary).Open(rewrapped, PayloadAAD("tenant-a"))
+	if err != nil || string(got) != "payload" {
+		t.Fatalf("Open after rotation = %q, %v", got, err)
+	}
+}
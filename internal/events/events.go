@@ -0,0 +1,300 @@
+// Package events delivers job status changes to API clients. A trigger on
+// jobs writes each change to job_events and notifies the job_events channel;
+// Hub listens on that channel and fans events out to subscribers, and Replay
+// reads the table so clients can resume from an event ID.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const channel = "job_events"
+
+type Event struct {
+	ID             int64             `json:"id"`
+	JobID          int               `json:"job_id"`
+	TenantID       string            `json:"tenant_id"`
+	Type           string            `json:"type"`
+	Status         string            `json:"status"`
+	PreviousStatus string            `json:"previous_status,omitempty"`
+	Attempts       int               `json:"attempts"`
+	LastError      string            `json:"last_error,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+const eventColumns = `id, job_id, tenant_id, type, status, COALESCE(previous_status, ''), attempts,
+	COALESCE(last_error, ''), labels, created_at`
+
+func scanEvent(row pgx.Row, event *Event) error {
+	return row.Scan(&event.ID, &event.JobID, &event.TenantID, &event.Type, &event.Status,
+		&event.PreviousStatus, &event.Attempts, &event.LastError, &event.Labels, &event.CreatedAt)
+}
+
+// Filter selects the events a subscriber receives. Empty fields match
+// everything; Labels must all match.
+type Filter struct {
+	TenantID string
+	JobIDs   []int
+	Types    []models.JOB_TYPE
+	Statuses []models.JOB_STATUS
+	Labels   map[string]string
+}
+
+func (f Filter) Matches(event Event) bool {
+	if event.TenantID != f.TenantID {
+		return false
+	}
+	if len(f.JobIDs) > 0 && !slices.Contains(f.JobIDs, event.JobID) {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, models.JOB_TYPE(event.Type)) {
+		return false
+	}
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, models.JOB_STATUS(event.Status)) {
+		return false
+	}
+	for key, value := range f.Labels {
+		if event.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Replay returns up to limit stored events after afterID that match filter,
+// oldest first.
+func Replay(ctx context.Context, pool *pgxpool.Pool, filter Filter, afterID int64, limit int) ([]Event, error) {
+	where := []string{"tenant_id = $1", "id > $2"}
+	args := []interface{}{filter.TenantID, afterID}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if len(filter.JobIDs) > 0 {
+		where = append(where, "job_id = ANY("+arg(filter.JobIDs)+")")
+	}
+	if len(filter.Types) > 0 {
+		where = append(where, "type = ANY("+arg(toStrings(filter.Types))+")")
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(toStrings(filter.Statuses))+")")
+	}
+	if len(filter.Labels) > 0 {
+		labels, err := json.Marshal(filter.Labels)
+		if err != nil {
+			return nil, err
+		}
+		where = append(where, "labels @> "+arg(string(labels))+"::jsonb")
+	}
+	query := "SELECT " + eventColumns + " FROM job_events WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY id LIMIT " + arg(limit)
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var event Event
+		if err := scanEvent(rows, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// Subscription receives the live events matching its filter. Events is
+// closed when the subscriber falls too far behind or the hub shuts down;
+// the client is expected to reconnect with the last ID it saw.
+type Subscription struct {
+	Events chan Event
+	filter Filter
+}
+
+type Hub struct {
+	pool *pgxpool.Pool
+	log  *zap.SugaredLogger
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	lastID      int64
+}
+
+func NewHub(pool *pgxpool.Pool, log *zap.SugaredLogger) *Hub {
+	return &Hub{pool: pool, log: log, subscribers: map[*Subscription]struct{}{}}
+}
+
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	sub := &Subscription{Events: make(chan Event, config.EVENT_SUBSCRIBER_BUFFER), filter: filter}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.Events)
+		return sub
+	}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.Events)
+	}
+}
+
+// Close ends every subscription so open streams return, e.g. on server
+// shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		delete(h.subscribers, sub)
+		close(sub.Events)
+	}
+}
+
+func (h *Hub) broadcast(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID = max(h.lastID, event.ID)
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+		select {
+		case sub.Events <- event:
+		default:
+			// Dropping the subscriber keeps one slow client from holding
+			// up the rest; it resumes from its last event ID.
+			delete(h.subscribers, sub)
+			close(sub.Events)
+		}
+	}
+}
+
+// Run listens for new events until ctx is done, reconnecting after errors,
+// and prunes events older than config.JOB_EVENT_RETENTION.
+func (h *Hub) Run(ctx context.Context) {
+	go h.prune(ctx)
+	for {
+		err := h.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		h.log.Warnf("Job event listener stopped, reconnecting: %v", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (h *Hub) listen(ctx context.Context) error {
+	pooled, err := h.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection stays in LISTEN mode, so it must not go back to the
+	// pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	if err := h.catchUp(ctx); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			h.log.Warnf("Ignoring malformed job event notification %q", notification.Payload)
+			continue
+		}
+		var event Event
+		err = scanEvent(h.pool.QueryRow(ctx, "SELECT "+eventColumns+" FROM job_events WHERE id = $1", id), &event)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		h.broadcast(event)
+	}
+}
+
+// catchUp broadcasts events committed while the listener was disconnected.
+func (h *Hub) catchUp(ctx context.Context) error {
+	h.mu.Lock()
+	lastID := h.lastID
+	h.mu.Unlock()
+	if lastID == 0 {
+		return nil
+	}
+
+	rows, err := h.pool.Query(ctx, "SELECT "+eventColumns+" FROM job_events WHERE id > $1 ORDER BY id", lastID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event Event
+		if err := scanEvent(rows, &event); err != nil {
+			return err
+		}
+		h.broadcast(event)
+	}
+	return rows.Err()
+}
+
+func (h *Hub) prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		_, err := h.pool.Exec(ctx, "DELETE FROM job_events WHERE created_at < $1", time.Now().Add(-config.JOB_EVENT_RETENTION))
+		if err != nil && ctx.Err() == nil {
+			h.log.Warnf("Failed to prune job events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = string(value)
+	}
+	return out
+}
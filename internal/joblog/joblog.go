@@ -0,0 +1,146 @@
+package joblog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const truncatedLine = `{"level":"warn","msg":"job log capture truncated"}`
+
+// Capture accumulates the JSON-encoded log lines of a single job execution,
+// up to maxBytes. Lines past the cap are dropped and a marker is appended.
+type Capture struct {
+	mu        sync.Mutex
+	lines     []string
+	size      int
+	maxBytes  int
+	truncated bool
+}
+
+func (c *Capture) append(line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.truncated {
+		return
+	}
+	if c.size+len(line) > c.maxBytes {
+		c.truncated = true
+		return
+	}
+	c.lines = append(c.lines, line)
+	c.size += len(line)
+}
+
+func (c *Capture) Lines() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lines := append([]string(nil), c.lines...)
+	if c.truncated {
+		lines = append(lines, truncatedLine)
+	}
+	return lines
+}
+
+type captureCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	capture *Capture
+}
+
+func (core *captureCore) With(fields []zapcore.Field) zapcore.Core {
+	encoder := core.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+	return &captureCore{LevelEnabler: core.LevelEnabler, encoder: encoder, capture: core.capture}
+}
+
+func (core *captureCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core.Enabled(entry.Level) {
+		return checked.AddCore(entry, core)
+	}
+	return checked
+}
+
+func (core *captureCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := core.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	core.capture.append(strings.TrimSuffix(buf.String(), "\n"))
+	buf.Free()
+	return nil
+}
+
+func (core *captureCore) Sync() error {
+	return nil
+}
+
+// NewJobLogger returns a logger for one execution of job, pre-tagged with
+// job_id, attempt and type. Everything it logs still reaches base, and a copy
+// is kept in the returned Capture for Store.Append.
+func NewJobLogger(base *zap.SugaredLogger, job models.RedisJobType) (*zap.SugaredLogger, *Capture) {
+	capture := &Capture{maxBytes: config.JOB_LOG_MAX_BYTES_PER_ATTEMPT}
+
+	logger := base.Desugar().WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &captureCore{
+			LevelEnabler: core,
+			encoder:      zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+			capture:      capture,
+		})
+	}))
+
+	return logger.Sugar().With(
+		"job_id", job.JobID,
+		"attempt", job.Retries+1,
+		"type", job.Type,
+	), capture
+}
+
+// Store keeps captured job logs in a capped, expiring Redis list per job.
+type Store struct {
+	RedisClient *redis.Client
+}
+
+func NewStore(redisClient *redis.Client) *Store {
+	return &Store{RedisClient: redisClient}
+}
+
+func redisKey(jobID int) string {
+	return fmt.Sprintf("job_logs:%d", jobID)
+}
+
+// Append adds lines to the job's log, keeping only the newest
+// config.JOB_LOG_MAX_LINES lines across all attempts.
+func (store *Store) Append(ctx context.Context, jobID int, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	values := make([]interface{}, len(lines))
+	for i, line := range lines {
+		values[i] = line
+	}
+
+	key := redisKey(jobID)
+	pipe := store.RedisClient.TxPipeline()
+	pipe.RPush(ctx, key, values...)
+	pipe.LTrim(ctx, key, -config.JOB_LOG_MAX_LINES, -1)
+	pipe.Expire(ctx, key, config.JOB_LOG_TTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (store *Store) Fetch(ctx context.Context, jobID int) ([]string, error) {
+	return store.RedisClient.LRange(ctx, redisKey(jobID), 0, -1).Result()
+}
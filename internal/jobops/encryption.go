@@ -0,0 +1,107 @@
+package jobops
+
+import (
+	"context"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/envelope"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Reveal decrypts job's payload in place. It is a no-op for plaintext jobs.
+func Reveal(keyring *envelope.Keyring, job *models.Job) error {
+	if job.Encrypted == nil {
+		return nil
+	}
+	if keyring == nil {
+		return envelope.ErrUnknownKey
+	}
+	plaintext, err := keyring.Open(*job.Encrypted, envelope.PayloadAAD(job.TenantID))
+	if err != nil {
+		return err
+	}
+	job.Payload = plaintext
+	return nil
+}
+
+// Redact withholds job's payload, including the data and message fields
+// generated from it.
+func Redact(job *models.Job) {
+	job.Payload, job.Data, job.Message = nil, "", ""
+	job.PayloadRedacted = true
+}
+
+// ReencryptResult counts the rows one Reencrypt pass changed.
+type ReencryptResult struct {
+	Rewrapped int
+	Encrypted int
+	LastID    int
+}
+
+// Reencrypt moves up to limit jobs with an ID above afterID onto the
+// keyring's primary master key: encrypted rows get their data key rewrapped
+// and, when includePlaintext is set, plaintext rows are encrypted. Callers
+// loop on LastID until a pass returns LastID == afterID.
+func Reencrypt(ctx context.Context, pool *pgxpool.Pool, keyring *envelope.Keyring, afterID, limit int, includePlaintext bool) (ReencryptResult, error) {
+	result := ReencryptResult{LastID: afterID}
+	rows, err := pool.Query(ctx, `
+		SELECT `+jobColumns+` FROM jobs
+		WHERE id > $1 AND (payload_key_id IS DISTINCT FROM $2) AND ($3 OR payload_key_id IS NOT NULL)
+		ORDER BY id
+		LIMIT $4
+	`, afterID, keyring.PrimaryID(), includePlaintext, limit)
+	if err != nil {
+		return result, err
+	}
+	var jobs []models.Job
+	for rows.Next() {
+		var job models.Job
+		if err := scanJob(rows, &job); err != nil {
+			rows.Close()
+			return result, err
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	for _, job := range jobs {
+		var sealed models.EncryptedPayload
+		if job.Encrypted != nil {
+			sealed, _, err = keyring.Rewrap(*job.Encrypted)
+		} else {
+			sealed, err = keyring.Seal(job.Payload, envelope.PayloadAAD(job.TenantID))
+		}
+		if err != nil {
+			return result, err
+		}
+
+		// The key ID condition keeps a concurrent writer's change from being
+		// overwritten with a stale envelope.
+		tag, err := pool.Exec(ctx, `
+			UPDATE jobs SET payload = '{}', payload_key_id = $2, payload_dek = $3, payload_ciphertext = $4
+			WHERE id = $1 AND payload_key_id IS NOT DISTINCT FROM $5
+		`, job.ID, sealed.KeyID, sealed.WrappedKey, sealed.Ciphertext, keyIDOf(job.Encrypted))
+		if err != nil {
+			return result, err
+		}
+		if tag.RowsAffected() > 0 {
+			if job.Encrypted != nil {
+				result.Rewrapped++
+			} else {
+				result.Encrypted++
+			}
+		}
+		result.LastID = job.ID
+	}
+	return result, nil
+}
+
+func keyIDOf(payload *models.EncryptedPayload) *string {
+	if payload == nil {
+		return nil
+	}
+	return &payload.KeyID
+}
@@ -0,0 +1,318 @@
+// Package jobops holds job operations shared by the API, the admin
+// dashboard and the worker. Functions that take a tenantID scope the
+// operation to that tenant; an empty tenantID means any tenant and is only
+// passed by admin-facing callers.
+package jobops
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/lifecycle"
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/queue"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrInvalidState = errors.New("job is not in a state that allows this operation")
+)
+
+// Enqueue adds jobID to the sorted set of its priority, due at executionAt.
+// Members are bare job IDs, so enqueueing a job that is already queued only
+// moves its due time; everything else is read from Postgres at claim time.
+func Enqueue(ctx context.Context, redisClient *redis.Client, jobID int, priority models.JOB_PRIORITY, executionAt time.Time) error {
+	return redisClient.ZAdd(ctx, queue.RedisKey(priority), &redis.Z{
+		Score:  float64(executionAt.Unix()),
+		Member: strconv.Itoa(jobID),
+	}).Err()
+}
+
+// Dequeue removes jobID from every priority queue.
+func Dequeue(ctx context.Context, redisClient *redis.Client, jobID int) error {
+	_, err := redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, priority := range queue.Priorities {
+			pipe.ZRem(ctx, queue.RedisKey(priority), strconv.Itoa(jobID))
+		}
+		return nil
+	})
+	return err
+}
+
+// MemberJobID returns the job ID of a sorted-set member. Members written
+// before queues held bare IDs are JSON objects carrying the ID under job_id,
+// or under JobID when written by releases whose struct tag was malformed; the
+// leading '{' is what tells the two formats apart. A bare decimal ID is
+// already smaller and cheaper to decode than a binary encoding of the job
+// would be (see BenchmarkDecodeMembers), so members have no other format.
+func MemberJobID(member string) (int, error) {
+	var jobID int
+	if strings.HasPrefix(member, "{") {
+		var legacy struct {
+			JobID         int `json:"job_id"`
+			UntaggedJobID int `json:"JobID"`
+		}
+		if err := json.Unmarshal([]byte(member), &legacy); err != nil {
+			return 0, fmt.Errorf("decode legacy queue member: %w", err)
+		}
+		jobID = legacy.JobID
+		if jobID == 0 {
+			jobID = legacy.UntaggedJobID
+		}
+	} else {
+		var err error
+		if jobID, err = strconv.Atoi(member); err != nil {
+			return 0, fmt.Errorf("decode queue member: %w", err)
+		}
+	}
+	if jobID <= 0 {
+		return 0, fmt.Errorf("queue member has invalid job ID %d", jobID)
+	}
+	return jobID, nil
+}
+
+// recordEvent appends event to the lifecycle stream, logging failures with
+// the request's logger.
+func recordEvent(ctx context.Context, redisClient *redis.Client, event lifecycle.Event) {
+	lifecycle.NewLog(redisClient).Record(ctx, config.LoggerFromContext(ctx).Sugar(), event)
+}
+
+// QueuedAt reports which priority queue holds jobID and when it is due.
+func QueuedAt(ctx context.Context, redisClient *redis.Client, jobID int) (models.JOB_PRIORITY, time.Time, bool, error) {
+	for _, priority := range queue.Priorities {
+		score, err := redisClient.ZScore(ctx, queue.RedisKey(priority), strconv.Itoa(jobID)).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return "", time.Time{}, false, err
+		}
+		return priority, time.Unix(int64(score), 0), true, nil
+	}
+	return "", time.Time{}, false, nil
+}
+
+const jobColumns = `id, tenant_id, type, payload, COALESCE(data, ''), COALESCE(message, ''), priority, status, attempts,
+	COALESCE(last_error, ''), labels, created_at, execution_at, updated_at, retry_policy, progress,
+	COALESCE(callback_url, ''), callback_events, payload_key_id, payload_dek, payload_ciphertext`
+
+func scanJob(row pgx.Row, job *models.Job) error {
+	var keyID *string
+	var wrappedKey, ciphertext []byte
+	err := row.Scan(
+		&job.ID, &job.TenantID, &job.Type, &job.Payload, &job.Data, &job.Message, &job.Priority,
+		&job.Status, &job.Attempts, &job.LastError, &job.Labels, &job.CreatedAt, &job.ExecutionAt,
+		&job.UpdatedAt, &job.RetryPolicy, &job.Progress, &job.CallbackURL, &job.CallbackEvents,
+		&keyID, &wrappedKey, &ciphertext,
+	)
+	if err == nil && keyID != nil {
+		job.Payload = nil
+		job.Encrypted = &models.EncryptedPayload{KeyID: *keyID, WrappedKey: wrappedKey, Ciphertext: ciphertext}
+	}
+	return err
+}
+
+func Get(ctx context.Context, pool *pgxpool.Pool, tenantID string, jobID int) (models.Job, error) {
+	var job models.Job
+	err := scanJob(pool.QueryRow(ctx,
+		"SELECT "+jobColumns+" FROM jobs WHERE id = $1 AND ($2 = '' OR tenant_id = $2)",
+		jobID, tenantID,
+	), &job)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Job{}, ErrJobNotFound
+	}
+	return job, err
+}
+
+// Filter narrows Recent. Zero values match everything.
+type Filter struct {
+	TenantID string
+	Status   models.JOB_STATUS
+	Type     models.JOB_TYPE
+	// ScheduledOnly keeps jobs whose execution time is in the future and
+	// orders them soonest first.
+	ScheduledOnly bool
+	Limit         int
+}
+
+// Recent returns the newest jobs matching filter.
+func Recent(ctx context.Context, pool *pgxpool.Pool, filter Filter) ([]models.Job, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 100
+	}
+	order := "created_at DESC, id DESC"
+	if filter.ScheduledOnly {
+		order = "execution_at, id"
+	}
+	rows, err := pool.Query(ctx, `
+		SELECT `+jobColumns+` FROM jobs
+		WHERE ($1 = '' OR tenant_id = $1) AND ($2 = '' OR status = $2) AND ($3 = '' OR type = $3)
+			AND (NOT $4 OR execution_at > now())
+		ORDER BY `+order+`
+		LIMIT $5
+	`, filter.TenantID, string(filter.Status), string(filter.Type), filter.ScheduledOnly, filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []models.Job{}
+	for rows.Next() {
+		var job models.Job
+		if err := scanJob(rows, &job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func Attempts(ctx context.Context, pool *pgxpool.Pool, jobID int) ([]models.JobAttempt, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT attempt, started_at, finished_at, outcome, COALESCE(error, '')
+		FROM job_attempts WHERE job_id = $1 ORDER BY attempt, id
+	`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.JobAttempt{}
+	for rows.Next() {
+		var attempt models.JobAttempt
+		if err := rows.Scan(&attempt.Attempt, &attempt.StartedAt, &attempt.FinishedAt, &attempt.Outcome, &attempt.Error); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, rows.Err()
+}
+
+// stateError distinguishes a missing job from one in the wrong state after
+// a conditional UPDATE matched no rows.
+func stateError(ctx context.Context, pool *pgxpool.Pool, tenantID string, jobID int) error {
+	if _, err := Get(ctx, pool, tenantID, jobID); err != nil {
+		return err
+	}
+	return ErrInvalidState
+}
+
+// Retry puts a failed, dead-lettered or cancelled job back on its queue with
+// a fresh retry budget. It keeps the job's ID and attempt history.
+func Retry(ctx context.Context, pool *pgxpool.Pool, redisClient *redis.Client, tenantID string, jobID int) error {
+	var job models.Job
+	err := scanJob(pool.QueryRow(ctx, `
+		UPDATE jobs SET status = $3, retries = 0, last_error = NULL, execution_at = now(), updated_at = now()
+		WHERE id = $1 AND ($2 = '' OR tenant_id = $2) AND status = ANY($4)
+		RETURNING `+jobColumns,
+		jobID, tenantID, models.JOB_STATUS_QUEUED,
+		[]string{string(models.JOB_STATUS_FAILED), string(models.JOB_STATUS_DEAD_LETTER), string(models.JOB_STATUS_CANCELLED)},
+	), &job)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return stateError(ctx, pool, tenantID, jobID)
+	}
+	if err != nil {
+		return err
+	}
+	recordEvent(ctx, redisClient, lifecycle.FromJob(lifecycle.EVENT_RETRIED, job))
+	return Enqueue(ctx, redisClient, job.ID, models.JOB_PRIORITY(job.Priority), job.ExecutionAt)
+}
+
+// Cancel stops a queued job from running and removes it from Redis. The
+// worker also skips cancelled jobs when it claims them, which covers a job
+// already picked up by a poller.
+func Cancel(ctx context.Context, pool *pgxpool.Pool, redisClient *redis.Client, tenantID string, jobID int) error {
+	var job models.Job
+	err := scanJob(pool.QueryRow(ctx, `
+		UPDATE jobs SET status = $3, updated_at = now()
+		WHERE id = $1 AND ($2 = '' OR tenant_id = $2) AND status = $4
+		RETURNING `+jobColumns,
+		jobID, tenantID, models.JOB_STATUS_CANCELLED, models.JOB_STATUS_QUEUED,
+	), &job)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return stateError(ctx, pool, tenantID, jobID)
+	}
+	if err != nil {
+		return err
+	}
+	recordEvent(ctx, redisClient, lifecycle.FromJob(lifecycle.EVENT_CANCELLED, job))
+	return Dequeue(ctx, redisClient, jobID)
+}
+
+// Replay submits a copy of a dead-lettered job as a new job and returns the
+// new job's ID. The original stays in the dead-letter list for reference.
+func Replay(ctx context.Context, pool *pgxpool.Pool, redisClient *redis.Client, tenantID string, jobID int) (int, error) {
+	var job models.Job
+	err := scanJob(pool.QueryRow(ctx, `
+		INSERT INTO jobs (tenant_id, type, payload, priority, labels, delay_seconds, created_at, execution_at,
+			retry_policy, callback_url, callback_events, payload_key_id, payload_dek, payload_ciphertext)
+		SELECT tenant_id, type, payload, priority, labels, 0, now(), now(),
+			retry_policy, callback_url, callback_events, payload_key_id, payload_dek, payload_ciphertext
+		FROM jobs WHERE id = $1 AND ($2 = '' OR tenant_id = $2) AND status = $3
+		RETURNING `+jobColumns,
+		jobID, tenantID, models.JOB_STATUS_DEAD_LETTER,
+	), &job)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, stateError(ctx, pool, tenantID, jobID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	recordEvent(ctx, redisClient, lifecycle.FromJob(lifecycle.EVENT_SUBMITTED, job))
+	return job.ID, Enqueue(ctx, redisClient, job.ID, models.JOB_PRIORITY(job.Priority), job.ExecutionAt)
+}
+
+// QueueDepths returns how many jobs sit in each priority's sorted set and
+// how many of those are already due.
+func QueueDepths(ctx context.Context, redisClient *redis.Client) (map[models.JOB_PRIORITY]QueueDepth, error) {
+	now := fmt.Sprintf("%d", time.Now().Unix())
+	depths := map[models.JOB_PRIORITY]QueueDepth{}
+	for _, priority := range queue.Priorities {
+		key := queue.RedisKey(priority)
+		total, err := redisClient.ZCard(ctx, key).Result()
+		if err != nil {
+			return nil, err
+		}
+		due, err := redisClient.ZCount(ctx, key, "-inf", now).Result()
+		if err != nil {
+			return nil, err
+		}
+		depths[priority] = QueueDepth{Total: total, Due: due}
+	}
+	return depths, nil
+}
+
+type QueueDepth struct {
+	Total int64 `json:"total"`
+	Due   int64 `json:"due"`
+}
+
+// StatusCounts returns the number of jobs per status.
+func StatusCounts(ctx context.Context, pool *pgxpool.Pool, tenantID string) (map[string]int, error) {
+	rows, err := pool.Query(ctx,
+		"SELECT status, count(*) FROM jobs WHERE ($1 = '' OR tenant_id = $1) GROUP BY status", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,214 @@
+package jobops
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+// The benchmarks decode one poll's worth of sorted-set members. "json" is
+// the member format used before queues held bare IDs, decoded both the way
+// workers used to (the whole job) and the way MemberJobID reads it now.
+// "binary" is a compact versioned encoding of the same job, measured to
+// compare it with bare IDs; members are never written in it.
+
+const (
+	FORMAT_ID     = "id"
+	FORMAT_JSON   = "json"
+	FORMAT_BINARY = "binary"
+)
+
+func benchmarkJob(id int) models.RedisJobType {
+	return models.RedisJobType{
+		JobID:       id,
+		TenantID:    "tenant-a",
+		Type:        models.JOB_TYPE_EMAIL,
+		Payload:     json.RawMessage(`{"to":["user@example.com"],"subject":"Welcome","body":"Thanks for signing up."}`),
+		ExecutionAt: time.Unix(1_700_000_000, 0).UTC(),
+		Priority:    models.JOB_PRIORITY_HIGH,
+	}
+}
+
+// encodeBinary writes a version byte, the ID and execution time as varints,
+// and the tenant, type, priority and payload as length-prefixed strings.
+func encodeBinary(job models.RedisJobType) string {
+	buf := []byte{1}
+	buf = binary.AppendUvarint(buf, uint64(job.JobID))
+	buf = binary.AppendVarint(buf, job.ExecutionAt.Unix())
+	for _, field := range []string{job.TenantID, string(job.Type), string(job.Priority), string(job.Payload)} {
+		buf = binary.AppendUvarint(buf, uint64(len(field)))
+		buf = append(buf, field...)
+	}
+	return string(buf)
+}
+
+func decodeBinary(member string) (models.RedisJobType, error) {
+	b := []byte(member)
+	if len(b) == 0 || b[0] != 1 {
+		return models.RedisJobType{}, errors.New("unknown member version")
+	}
+	b = b[1:]
+	id, n := binary.Uvarint(b)
+	if n <= 0 {
+		return models.RedisJobType{}, errors.New("truncated member")
+	}
+	b = b[n:]
+	executionAt, n := binary.Varint(b)
+	if n <= 0 {
+		return models.RedisJobType{}, errors.New("truncated member")
+	}
+	b = b[n:]
+	var fields [4]string
+	for i := range fields {
+		length, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < length {
+			return models.RedisJobType{}, errors.New("truncated member")
+		}
+		fields[i] = string(b[n : n+int(length)])
+		b = b[n+int(length):]
+	}
+	return models.RedisJobType{
+		JobID:       int(id),
+		ExecutionAt: time.Unix(executionAt, 0),
+		TenantID:    fields[0],
+		Type:        models.JOB_TYPE(fields[1]),
+		Priority:    models.JOB_PRIORITY(fields[2]),
+		Payload:     json.RawMessage(fields[3]),
+	}, nil
+}
+
+func queueMembers(b *testing.B, format string) []string {
+	b.Helper()
+	members := make([]string, config.BATCH_SIZE)
+	for i := range members {
+		job := benchmarkJob(1_000_000 + i)
+		switch format {
+		case FORMAT_ID:
+			members[i] = strconv.Itoa(job.JobID)
+		case FORMAT_BINARY:
+			members[i] = encodeBinary(job)
+		default:
+			raw, err := json.Marshal(job)
+			if err != nil {
+				b.Fatal(err)
+			}
+			members[i] = string(raw)
+		}
+	}
+	return members
+}
+
+func reportMemberBytes(b *testing.B, members []string) {
+	total := 0
+	for _, member := range members {
+		total += len(member)
+	}
+	b.ReportMetric(float64(total)/float64(len(members)), "bytes/member")
+}
+
+func BenchmarkDecodeMembers(b *testing.B) {
+	b.Run("json/full", func(b *testing.B) {
+		members := queueMembers(b, FORMAT_JSON)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, member := range members {
+				var job models.RedisJobType
+				if err := json.Unmarshal([]byte(member), &job); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		reportMemberBytes(b, members)
+	})
+	b.Run("json/id", func(b *testing.B) {
+		members := queueMembers(b, FORMAT_JSON)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, member := range members {
+				if _, err := MemberJobID(member); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		reportMemberBytes(b, members)
+	})
+	b.Run("id", func(b *testing.B) {
+		members := queueMembers(b, FORMAT_ID)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, member := range members {
+				if _, err := MemberJobID(member); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		reportMemberBytes(b, members)
+	})
+	b.Run("binary", func(b *testing.B) {
+		members := queueMembers(b, FORMAT_BINARY)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, member := range members {
+				if _, err := decodeBinary(member); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		reportMemberBytes(b, members)
+	})
+}
+
+func TestBinaryBenchmarkEncoding(t *testing.T) {
+	job := benchmarkJob(42)
+	got, err := decodeBinary(encodeBinary(job))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.JobID != job.JobID || got.TenantID != job.TenantID || got.Type != job.Type ||
+		got.Priority != job.Priority || !got.ExecutionAt.Equal(job.ExecutionAt) || string(got.Payload) != string(job.Payload) {
+		t.Errorf("decodeBinary = %+v, want %+v", got, job)
+	}
+}
+
+func TestMemberJobID(t *testing.T) {
+	tests := []struct {
+		name    string
+		member  string
+		want    int
+		wantErr bool
+	}{
+		{name: "bare id", member: "42", want: 42},
+		{name: "legacy job_id", member: `{"job_id":42,"type":"Email"}`, want: 42},
+		// Written by releases whose job_id struct tag lacked its closing
+		// quote, so encoding/json used the field name.
+		{name: "legacy JobID", member: `{"JobID":42,"type":"Email"}`, want: 42},
+		{name: "legacy without id", member: `{"type":"Email"}`, wantErr: true},
+		{name: "legacy zero id", member: `{"job_id":0}`, wantErr: true},
+		{name: "legacy negative id", member: `{"JobID":-3}`, wantErr: true},
+		{name: "malformed json", member: `{"job_id":`, wantErr: true},
+		{name: "zero", member: "0", wantErr: true},
+		{name: "negative", member: "-7", wantErr: true},
+		{name: "not a number", member: "job-42", wantErr: true},
+		{name: "empty", member: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MemberJobID(tt.member)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("MemberJobID(%q) = %d, want an error", tt.member, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("MemberJobID(%q) = %d, %v; want %d", tt.member, got, err, tt.want)
+			}
+		})
+	}
+}
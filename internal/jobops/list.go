@@ -0,0 +1,296 @@
+package jobops
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrInvalidCursor = errors.New("invalid or mismatched cursor")
+
+// SORT_FIELD is a column List may order by. Each one has a
+// (tenant_id, column, id) index so keyset pages stay cheap.
+type SORT_FIELD string
+
+const (
+	SORT_CREATED_AT   SORT_FIELD = "created_at"
+	SORT_EXECUTION_AT SORT_FIELD = "execution_at"
+	SORT_UPDATED_AT   SORT_FIELD = "updated_at"
+	SORT_ID           SORT_FIELD = "id"
+)
+
+func IsValidSortField(s string) (SORT_FIELD, bool) {
+	field := SORT_FIELD(s)
+	switch field {
+	case SORT_CREATED_AT, SORT_EXECUTION_AT, SORT_UPDATED_AT, SORT_ID:
+		return field, true
+	default:
+		return "", false
+	}
+}
+
+// ListQuery selects one page of a tenant's jobs. Empty slices, zero times
+// and a nil Labels map match everything; Labels must all match.
+type ListQuery struct {
+	TenantID        string
+	Statuses        []models.JOB_STATUS
+	Types           []models.JOB_TYPE
+	Priorities      []models.JOB_PRIORITY
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	ExecutionAfter  time.Time
+	ExecutionBefore time.Time
+	Labels          map[string]string
+	// PayloadMatches must all hold. Text searches are case-sensitive and
+	// match the data and message fields of the payload.
+	PayloadMatches  []PayloadMatch
+	DataPrefix      string
+	DataContains    string
+	MessagePrefix   string
+	MessageContains string
+	Sort            SORT_FIELD
+	Descending      bool
+	Cursor          string
+	Limit           int
+}
+
+// FiltersPayload reports whether q matches on payload contents, which only
+// plaintext payloads can satisfy.
+func (q ListQuery) FiltersPayload() bool {
+	return len(q.PayloadMatches) > 0 || q.DataPrefix != "" || q.DataContains != "" ||
+		q.MessagePrefix != "" || q.MessageContains != ""
+}
+
+// PayloadMatch selects jobs whose payload has Value at Path. Value matches
+// a JSON string, and also a number, boolean or null when it parses as one.
+type PayloadMatch struct {
+	Path  []string
+	Value string
+}
+
+// containment returns the jsonb documents any of which the payload must
+// contain for m to hold.
+func (m PayloadMatch) containment() ([]string, error) {
+	candidates := []interface{}{m.Value}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(m.Value), &parsed); err == nil {
+		switch parsed.(type) {
+		case float64, bool, nil:
+			candidates = append(candidates, json.RawMessage(m.Value))
+		}
+	}
+
+	docs := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		doc := candidate
+		for i := len(m.Path) - 1; i >= 0; i-- {
+			doc = map[string]interface{}{m.Path[i]: doc}
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, string(raw))
+	}
+	return docs, nil
+}
+
+// likePattern escapes LIKE wildcards in s.
+func likePattern(prefix, s, suffix string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return prefix + escaped + suffix
+}
+
+type Page struct {
+	Jobs       []models.Job `json:"jobs"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// cursor is the sort key of the last row on a page. It records the sort it
+// was issued for so it cannot be replayed against a different ordering.
+type cursor struct {
+	Sort       SORT_FIELD `json:"s"`
+	Descending bool       `json:"d,omitempty"`
+	Value      time.Time  `json:"v"`
+	ID         int        `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+func sortValue(job models.Job, field SORT_FIELD) time.Time {
+	switch field {
+	case SORT_EXECUTION_AT:
+		return job.ExecutionAt
+	case SORT_UPDATED_AT:
+		return job.UpdatedAt
+	case SORT_CREATED_AT:
+		return job.CreatedAt
+	default:
+		return time.Time{}
+	}
+}
+
+// List returns one page of jobs matching q using keyset pagination on
+// (sort column, id). NextCursor is empty on the last page.
+func List(ctx context.Context, pool *pgxpool.Pool, q ListQuery) (Page, error) {
+	if q.Sort == "" {
+		q.Sort, q.Descending = SORT_CREATED_AT, true
+	}
+	if _, ok := IsValidSortField(string(q.Sort)); !ok {
+		return Page{}, fmt.Errorf("unsupported sort field %q", q.Sort)
+	}
+	if q.Limit <= 0 {
+		q.Limit = config.DEFAULT_LIST_LIMIT
+	}
+	if q.Limit > config.MAX_LIST_LIMIT {
+		q.Limit = config.MAX_LIST_LIMIT
+	}
+
+	var where []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where = append(where, "tenant_id = "+arg(q.TenantID))
+	if len(q.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(toStrings(q.Statuses))+")")
+	}
+	if len(q.Types) > 0 {
+		where = append(where, "type = ANY("+arg(toStrings(q.Types))+")")
+	}
+	if len(q.Priorities) > 0 {
+		where = append(where, "priority = ANY("+arg(toStrings(q.Priorities))+")")
+	}
+	if !q.CreatedAfter.IsZero() {
+		where = append(where, "created_at >= "+arg(q.CreatedAfter))
+	}
+	if !q.CreatedBefore.IsZero() {
+		where = append(where, "created_at < "+arg(q.CreatedBefore))
+	}
+	if !q.ExecutionAfter.IsZero() {
+		where = append(where, "execution_at >= "+arg(q.ExecutionAfter))
+	}
+	if !q.ExecutionBefore.IsZero() {
+		where = append(where, "execution_at < "+arg(q.ExecutionBefore))
+	}
+	if len(q.Labels) > 0 {
+		labels, err := json.Marshal(q.Labels)
+		if err != nil {
+			return Page{}, err
+		}
+		where = append(where, "labels @> "+arg(string(labels))+"::jsonb")
+	}
+	for _, match := range q.PayloadMatches {
+		docs, err := match.containment()
+		if err != nil {
+			return Page{}, err
+		}
+		var alternatives []string
+		for _, doc := range docs {
+			alternatives = append(alternatives, "payload @> "+arg(doc)+"::jsonb")
+		}
+		where = append(where, "("+strings.Join(alternatives, " OR ")+")")
+	}
+	searches := []struct {
+		column, value, prefix, suffix string
+	}{
+		{"data", q.DataPrefix, "", "%"},
+		{"data", q.DataContains, "%", "%"},
+		{"message", q.MessagePrefix, "", "%"},
+		{"message", q.MessageContains, "%", "%"},
+	}
+	for _, search := range searches {
+		if search.value != "" {
+			where = append(where, search.column+" LIKE "+arg(likePattern(search.prefix, search.value, search.suffix)))
+		}
+	}
+
+	comparison, direction := ">", "ASC"
+	if q.Descending {
+		comparison, direction = "<", "DESC"
+	}
+	if q.Cursor != "" {
+		c, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		if c.Sort != q.Sort || c.Descending != q.Descending {
+			return Page{}, ErrInvalidCursor
+		}
+		if q.Sort == SORT_ID {
+			where = append(where, "id "+comparison+" "+arg(c.ID))
+		} else {
+			where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", q.Sort, comparison, arg(c.Value), arg(c.ID)))
+		}
+	}
+
+	order := fmt.Sprintf("%s %s, id %s", q.Sort, direction, direction)
+	if q.Sort == SORT_ID {
+		order = "id " + direction
+	}
+	query := "SELECT " + jobColumns + " FROM jobs WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + order + " LIMIT " + arg(q.Limit+1)
+
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+
+	page := Page{Jobs: []models.Job{}}
+	for rows.Next() {
+		var job models.Job
+		if err := scanJob(rows, &job); err != nil {
+			return Page{}, err
+		}
+		page.Jobs = append(page.Jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, err
+	}
+
+	if len(page.Jobs) > q.Limit {
+		page.Jobs = page.Jobs[:q.Limit]
+		last := page.Jobs[len(page.Jobs)-1]
+		page.NextCursor = encodeCursor(cursor{
+			Sort:       q.Sort,
+			Descending: q.Descending,
+			Value:      sortValue(last, q.Sort),
+			ID:         last.ID,
+		})
+	}
+	return page, nil
+}
+
+func toStrings[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = string(value)
+	}
+	return out
+}
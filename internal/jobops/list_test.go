@@ -0,0 +1,59 @@
+package jobops
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursorRoundTrip(t *testing.T) {
+	want := cursor{Sort: SORT_EXECUTION_AT, Descending: true, Value: time.Unix(1_700_000_000, 123).UTC(), ID: 42}
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if got != want {
+		t.Errorf("cursor = %+v, want %+v", got, want)
+	}
+}
+
+// A rejected cursor never reaches Postgres, so List runs without a pool.
+func TestListRejectsTamperedCursor(t *testing.T) {
+	issued := encodeCursor(cursor{Sort: SORT_CREATED_AT, Descending: true, Value: time.Unix(1_700_000_000, 0).UTC(), ID: 42})
+	raw, err := base64.RawURLEncoding.DecodeString(issued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := base64.RawURLEncoding.EncodeToString(raw[:len(raw)-3])
+
+	tests := []struct {
+		name       string
+		cursor     string
+		sort       SORT_FIELD
+		descending bool
+	}{
+		{"not base64", "!!not-a-cursor!!", SORT_CREATED_AT, true},
+		{"padded base64", issued + "==", SORT_CREATED_AT, true},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("created_at|42")), SORT_CREATED_AT, true},
+		{"truncated json", truncated, SORT_CREATED_AT, true},
+		{"wrong value type", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"created_at","d":true,"v":"yesterday","id":42}`)), SORT_CREATED_AT, true},
+		{"replayed on another sort", issued, SORT_UPDATED_AT, true},
+		{"replayed in the other direction", issued, SORT_CREATED_AT, false},
+		{"sort field edited", base64.RawURLEncoding.EncodeToString([]byte(`{"s":"id","d":true,"v":"2023-11-14T22:13:20Z","id":42}`)), SORT_CREATED_AT, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := List(context.Background(), nil, ListQuery{
+				TenantID:   "tenant-a",
+				Sort:       tt.sort,
+				Descending: tt.descending,
+				Cursor:     tt.cursor,
+			})
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("err = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Package jobtypes is the registry of job types the system accepts. Each
+// type carries the JSON Schema its payload must satisfy; the API validates
+// submissions against it and the worker decodes the same payload into the
+// handler's own struct.
+package jobtypes
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+type Definition struct {
+	Type   models.JOB_TYPE
+	schema *jsonschema.Schema
+}
+
+var registry = map[models.JOB_TYPE]*Definition{}
+
+func init() {
+	for _, jobType := range []models.JOB_TYPE{models.JOB_TYPE_EMAIL, models.JOB_TYPE_MESSAGE, models.JOB_TYPE_WEBHOOK} {
+		raw, err := schemaFS.ReadFile("schemas/" + string(jobType) + ".json")
+		if err != nil {
+			panic(err)
+		}
+		if err := Register(jobType, raw); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Register compiles schema and makes jobType available for submission,
+// replacing any earlier definition.
+func Register(jobType models.JOB_TYPE, schema []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return fmt.Errorf("parse schema for %s: %w", jobType, err)
+	}
+	url := "jobtypes://" + string(jobType) + ".json"
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat()
+	if err := compiler.AddResource(url, doc); err != nil {
+		return fmt.Errorf("load schema for %s: %w", jobType, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("compile schema for %s: %w", jobType, err)
+	}
+	registry[jobType] = &Definition{Type: jobType, schema: compiled}
+	return nil
+}
+
+func Lookup(jobType models.JOB_TYPE) (*Definition, bool) {
+	definition, ok := registry[jobType]
+	return definition, ok
+}
+
+// Types returns the registered job types in a stable order.
+func Types() []models.JOB_TYPE {
+	types := make([]models.JOB_TYPE, 0, len(registry))
+	for jobType := range registry {
+		types = append(types, jobType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// FieldError is one validation failure. For schema violations Field is a
+// JSON pointer into the payload and the empty string means the payload as a
+// whole. Allowed lists the accepted values when the set is closed.
+type FieldError struct {
+	Field   string   `json:"field"`
+	Message string   `json:"message"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+type ValidationError struct {
+	Type   models.JOB_TYPE `json:"type"`
+	Fields []FieldError    `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, fmt.Sprintf("%s: %s", displayField(field.Field), field.Message))
+	}
+	return fmt.Sprintf("invalid %s payload: %s", e.Type, strings.Join(parts, "; "))
+}
+
+func displayField(field string) string {
+	if field == "" {
+		return "payload"
+	}
+	return field
+}
+
+// Validate checks payload against the schema of its job type and returns a
+// *ValidationError listing every violation.
+func (d *Definition) Validate(payload json.RawMessage) error {
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(payload))
+	if err != nil {
+		return &ValidationError{Type: d.Type, Fields: []FieldError{{Message: "payload is not valid JSON"}}}
+	}
+	err = d.schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+	schemaErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	result := &ValidationError{Type: d.Type}
+	seen := map[string]bool{}
+	for _, unit := range schemaErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		field := FieldError{Field: unit.InstanceLocation, Message: unit.Error.String()}
+		key := field.Field + "\x00" + field.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result.Fields = append(result.Fields, field)
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+// Package lifecycle appends every job lifecycle event to a Redis Stream for
+// downstream consumers such as analytics and billing. Consumers read the
+// stream with their own consumer groups, so each keeps its own offset and
+// can start over from any entry ID still retained.
+//
+// Every entry carries the same fields, with empty values where a field does
+// not apply:
+//
+//	version      SCHEMA_VERSION; bumped only for incompatible changes
+//	event        submitted, started, retried, completed, failed or cancelled
+//	job_id       the job's ID
+//	tenant_id    the job's tenant
+//	type         the job type
+//	priority     HIGH, MEDIUM or LOW
+//	status       the job's status after the event
+//	attempt      the attempt the event belongs to, 0 before the first
+//	error        the failure behind retried and failed
+//	occurred_at  RFC 3339 time with nanoseconds
+//
+// Appending is best-effort: a job operation never fails because its event
+// could not be written; Record only logs the error.
+package lifecycle
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+)
+
+const SCHEMA_VERSION = "1"
+
+type EVENT string
+
+const (
+	EVENT_SUBMITTED EVENT = "submitted"
+	// EVENT_STARTED is appended when a handler begins executing an attempt.
+	EVENT_STARTED EVENT = "started"
+	// EVENT_RETRIED is appended when a failed attempt is scheduled to run
+	// again and when an operator retries a job.
+	EVENT_RETRIED   EVENT = "retried"
+	EVENT_COMPLETED EVENT = "completed"
+	// EVENT_FAILED is appended when a job will not run again; status tells
+	// a dead-lettered job from one marked failed.
+	EVENT_FAILED    EVENT = "failed"
+	EVENT_CANCELLED EVENT = "cancelled"
+)
+
+type Event struct {
+	Event    EVENT
+	JobID    int
+	TenantID string
+	Type     models.JOB_TYPE
+	Priority models.JOB_PRIORITY
+	Status   models.JOB_STATUS
+	Attempt  int
+	Err      error
+}
+
+// FromJob describes event for a job as loaded from Postgres.
+func FromJob(event EVENT, job models.Job) Event {
+	return Event{
+		Event:    event,
+		JobID:    job.ID,
+		TenantID: job.TenantID,
+		Type:     models.JOB_TYPE(job.Type),
+		Priority: models.JOB_PRIORITY(job.Priority),
+		Status:   models.JOB_STATUS(job.Status),
+		Attempt:  job.Attempts,
+	}
+}
+
+func (e Event) values(occurredAt time.Time) map[string]interface{} {
+	errMsg := ""
+	if e.Err != nil {
+		errMsg = e.Err.Error()
+	}
+	return map[string]interface{}{
+		"version":     SCHEMA_VERSION,
+		"event":       string(e.Event),
+		"job_id":      strconv.Itoa(e.JobID),
+		"tenant_id":   e.TenantID,
+		"type":        string(e.Type),
+		"priority":    string(e.Priority),
+		"status":      string(e.Status),
+		"attempt":     strconv.Itoa(e.Attempt),
+		"error":       errMsg,
+		"occurred_at": occurredAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+type Log struct {
+	RedisClient *redis.Client
+}
+
+func NewLog(redisClient *redis.Client) *Log {
+	return &Log{RedisClient: redisClient}
+}
+
+// Append adds event to the stream and returns its entry ID.
+func (l *Log) Append(ctx context.Context, event Event) (string, error) {
+	return l.RedisClient.XAdd(ctx, &redis.XAddArgs{
+		Stream: config.LIFECYCLE_STREAM_KEY,
+		Values: event.values(time.Now()),
+	}).Result()
+}
+
+// Record appends event, logging a failure instead of returning it.
+func (l *Log) Record(ctx context.Context, log *zap.SugaredLogger, event Event) {
+	if _, err := l.Append(ctx, event); err != nil {
+		log.Warnf("Failed to append %s event for job %d to lifecycle stream: %v", event.Event, event.JobID, err)
+	}
+}
+
+// Trim drops entries beyond the newest maxLen and entries older than maxAge.
+// A zero limit is not applied. Trimming is approximate, so Redis can remove
+// whole nodes, and a few entries past either limit may remain.
+func (l *Log) Trim(ctx context.Context, maxLen int, maxAge time.Duration) error {
+	if maxLen > 0 {
+		if err := l.RedisClient.XTrimMaxLenApprox(ctx, config.LIFECYCLE_STREAM_KEY, int64(maxLen), 0).Err(); err != nil {
+			return err
+		}
+	}
+	if maxAge > 0 {
+		// Entry IDs start with their creation time in milliseconds.
+		minID := strconv.FormatInt(time.Now().Add(-maxAge).UnixMilli(), 10)
+		if err := l.RedisClient.XTrimMinIDApprox(ctx, config.LIFECYCLE_STREAM_KEY, minID, 0).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunTrimmer applies the configured retention every
+// config.LIFECYCLE_STREAM_TRIM_INTERVAL until ctx is done. Running it in
+// several processes at once is harmless.
+func (l *Log) RunTrimmer(ctx context.Context, settings *config.Live, log *zap.SugaredLogger) {
+	ticker := time.NewTicker(config.LIFECYCLE_STREAM_TRIM_INTERVAL)
+	defer ticker.Stop()
+	for {
+		cfg := settings.Get()
+		if err := l.Trim(ctx, cfg.LifecycleStreamMaxLen, cfg.LifecycleStreamMaxAge); err != nil && ctx.Err() == nil {
+			log.Warnf("Failed to trim lifecycle stream: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -4,14 +4,23 @@ import (
 	"go.uber.org/zap"
 )
 
-func SetupLogger() (*zap.Logger, error) {
-	logger, err := zap.NewProduction()
+// SetupLogger builds the production logger at the given level. The level can
+// be changed at runtime through the same zap.AtomicLevel.
+func SetupLogger(level zap.AtomicLevel) (*zap.Logger, error) {
+	cfg := zap.NewProductionConfig()
+	cfg.Level = level
+	logger, err := cfg.Build()
 	if err != nil {
 		return nil, err
 	}
 	return logger, nil
 }
 
+// ParseLevel returns an AtomicLevel for a level name such as "info".
+func ParseLevel(level string) (zap.AtomicLevel, error) {
+	return zap.ParseAtomicLevel(level)
+}
+
 func FetchSugaredLogger(logger *zap.Logger) *zap.SugaredLogger {
 	return logger.Sugar()
 }
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/breaker"
+	"github.com/alitto/pond/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	JobsSubmittedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_submitted_total",
+		Help: "Jobs accepted by the API, by type and priority.",
+	}, []string{"type", "priority"})
+
+	JobsProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_processed_total",
+		Help: "Job executions that completed successfully.",
+	}, []string{"type", "priority"})
+
+	JobsFailedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_failed_total",
+		Help: "Job executions that returned an error, including ones that will be retried.",
+	}, []string{"type", "priority"})
+
+	JobsRetriedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_retried_total",
+		Help: "Failed executions that were requeued for another attempt.",
+	}, []string{"type", "priority"})
+
+	JobsDeadLetteredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_dead_lettered_total",
+		Help: "Jobs that exhausted their retries and were given up on.",
+	}, []string{"type", "priority"})
+
+	JobProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_processing_duration_seconds",
+		Help:    "Time spent inside a job handler.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"type", "priority", "outcome"})
+
+	JobStartLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_enqueue_to_start_seconds",
+		Help:    "Delay between a job becoming due and a worker starting it.",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+	}, []string{"type", "priority"})
+
+	JobsDeferredTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_deferred_total",
+		Help: "Claimed jobs put back without running because their circuit breaker was open.",
+	}, []string{"type", "priority"})
+
+	CircuitBreakerTransitionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "circuit_breaker_transitions_total",
+		Help: "Circuit breaker state changes caused by job outcomes, by the state entered.",
+	}, []string{"type", "state"})
+
+	CallbackDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "callback_deliveries_total",
+		Help: "Job callback delivery attempts by event and outcome: delivered, retried or failed.",
+	}, []string{"event", "outcome"})
+
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served by the API.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests served by the API.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterQueueDepth exposes the cardinality of each sorted set in keys as a
+// gauge, sampled from Redis at scrape time.
+func RegisterQueueDepth(redisClient *redis.Client, keys []string) {
+	prometheus.MustRegister(&queueDepthCollector{redisClient: redisClient, keys: keys})
+}
+
+var queueDepthDesc = prometheus.NewDesc(
+	"queue_depth",
+	"Number of members in a Redis job sorted set.",
+	[]string{"queue"}, nil,
+)
+
+type queueDepthCollector struct {
+	redisClient *redis.Client
+	keys        []string
+}
+
+func (c *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+}
+
+func (c *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for _, key := range c.keys {
+		depth, err := c.redisClient.ZCard(ctx, key).Result()
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(queueDepthDesc, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(depth), key)
+	}
+}
+
+// RegisterPool exposes utilization of a pond worker pool.
+func RegisterPool(name string, pool pond.Pool) {
+	labels := prometheus.Labels{"pool": name}
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_running_workers", ConstLabels: labels,
+			Help: "Workers currently executing a task.",
+		}, func() float64 { return float64(pool.RunningWorkers()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_max_concurrency", ConstLabels: labels,
+			Help: "Maximum number of concurrent workers.",
+		}, func() float64 { return float64(pool.MaxConcurrency()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "pool_waiting_tasks", ConstLabels: labels,
+			Help: "Tasks submitted but not yet picked up by a worker.",
+		}, func() float64 { return float64(pool.WaitingTasks()) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "pool_completed_tasks_total", ConstLabels: labels,
+			Help: "Tasks the pool has finished running.",
+		}, func() float64 { return float64(pool.CompletedTasks()) }),
+	)
+}
+
+// RegisterCircuitBreakers exposes the state of every tracked breaker as a
+// gauge, read from Redis at scrape time: 0 closed, 1 half-open, 2 open.
+func RegisterCircuitBreakers(breakers *breaker.Breakers) {
+	prometheus.MustRegister(&breakerCollector{breakers: breakers})
+}
+
+var breakerStateDesc = prometheus.NewDesc(
+	"circuit_breaker_state",
+	"Circuit breaker state: 0 closed, 1 half-open, 2 open.",
+	[]string{"type", "destination"}, nil,
+)
+
+var breakerStateValues = map[breaker.STATE]float64{
+	breaker.STATE_CLOSED:    0,
+	breaker.STATE_HALF_OPEN: 1,
+	breaker.STATE_OPEN:      2,
+}
+
+type breakerCollector struct {
+	breakers *breaker.Breakers
+}
+
+func (c *breakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- breakerStateDesc
+}
+
+func (c *breakerCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	statuses, err := c.breakers.List(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(breakerStateDesc, err)
+		return
+	}
+	for _, status := range statuses {
+		ch <- prometheus.MustNewConstMetric(breakerStateDesc, prometheus.GaugeValue,
+			breakerStateValues[status.State], string(status.JobType), status.Destination)
+	}
+}
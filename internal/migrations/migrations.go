@@ -0,0 +1,317 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// advisoryLockID serialises concurrent migrate runs against one database.
+const advisoryLockID = 7_231_004
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// All returns the embedded migrations ordered by version. Files are named
+// NNNN_name.up.sql and NNNN_name.down.sql.
+func All() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		base, direction, ok := cutDirection(fileName)
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", fileName)
+		}
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s: expected NNNN_name prefix", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", fileName, err)
+		}
+
+		body, err := files.ReadFile("sql/" + fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	all := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s is missing its up or down file", migration.Version, migration.Name)
+		}
+		all = append(all, *migration)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Version < all[j].Version })
+	return all, nil
+}
+
+func cutDirection(fileName string) (string, string, bool) {
+	if base, ok := strings.CutSuffix(fileName, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(fileName, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// LatestVersion is the schema version this binary was built for.
+func LatestVersion() (int, error) {
+	all, err := All()
+	if err != nil {
+		return 0, err
+	}
+	if len(all) == 0 {
+		return 0, nil
+	}
+	return all[len(all)-1].Version, nil
+}
+
+func ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	return err
+}
+
+// Applied returns the migrations recorded in schema_migrations.
+func Applied(ctx context.Context, pool *pgxpool.Pool) ([]AppliedMigration, error) {
+	var exists bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := pool.Query(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var migration AppliedMigration
+		if err := rows.Scan(&migration.Version, &migration.Name, &migration.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied = append(applied, migration)
+	}
+	return applied, rows.Err()
+}
+
+// CurrentVersion is the highest applied migration, or 0 on an empty database.
+func CurrentVersion(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	applied, err := Applied(ctx, pool)
+	if err != nil {
+		return 0, err
+	}
+	if len(applied) == 0 {
+		return 0, nil
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the versions it applied.
+func Up(ctx context.Context, pool *pgxpool.Pool) ([]int, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		current, err := CurrentVersion(ctx, pool)
+		if err != nil {
+			return err
+		}
+		for _, migration := range all {
+			if migration.Version <= current {
+				continue
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("apply %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration.Version)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the newest steps applied migrations and returns the versions
+// it reverted.
+func Down(ctx context.Context, pool *pgxpool.Pool, steps int) ([]int, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]Migration{}
+	for _, migration := range all {
+		byVersion[migration.Version] = migration
+	}
+
+	var reverted []int
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		applied, err := Applied(ctx, pool)
+		if err != nil {
+			return err
+		}
+		for i := len(applied) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration, ok := byVersion[applied[i].Version]
+			if !ok {
+				return fmt.Errorf("applied migration %d is unknown to this binary", applied[i].Version)
+			}
+			err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("revert %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration.Version)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for databases whose schema was built by hand to that
+// version. It returns the versions it recorded.
+func Baseline(ctx context.Context, pool *pgxpool.Pool, version int) ([]int, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+	known := false
+	for _, migration := range all {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("baseline: unknown migration version %d", version)
+	}
+
+	var recorded []int
+	err = withLock(ctx, pool, func(conn *pgxpool.Conn) error {
+		return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			for _, migration := range all {
+				if migration.Version > version {
+					break
+				}
+				tag, err := tx.Exec(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2) ON CONFLICT (version) DO NOTHING",
+					migration.Version, migration.Name)
+				if err != nil {
+					return fmt.Errorf("record %04d_%s: %w", migration.Version, migration.Name, err)
+				}
+				if tag.RowsAffected() > 0 {
+					recorded = append(recorded, migration.Version)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+func withLock(ctx context.Context, pool *pgxpool.Pool, fn func(*pgxpool.Conn) error) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", advisoryLockID); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", advisoryLockID)
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+var ErrIncompatibleSchema = errors.New("incompatible schema version")
+
+// RequireLatest fails unless the database schema is exactly the version
+// this binary was built for. Both binaries call it at startup so they never
+// run against missing or unknown columns.
+func RequireLatest(ctx context.Context, pool *pgxpool.Pool) error {
+	latest, err := LatestVersion()
+	if err != nil {
+		return err
+	}
+	current, err := CurrentVersion(ctx, pool)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	switch {
+	case current < latest:
+		return fmt.Errorf("%w: database is at version %d, this binary needs %d; run `api migrate up`", ErrIncompatibleSchema, current, latest)
+	case current > latest:
+		return fmt.Errorf("%w: database is at version %d, newer than this binary's %d; upgrade the binary", ErrIncompatibleSchema, current, latest)
+	}
+	return nil
+}
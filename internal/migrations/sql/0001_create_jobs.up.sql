@@ -0,0 +1,18 @@
+-- Databases built by hand before migrations existed already have a jobs
+-- table, possibly from before tenants; adopt it instead of failing.
+CREATE TABLE IF NOT EXISTS jobs (
+    id            SERIAL PRIMARY KEY,
+    tenant_id     TEXT        NOT NULL DEFAULT 'default',
+    type          TEXT        NOT NULL,
+    data          TEXT        NOT NULL,
+    message       TEXT        NOT NULL,
+    priority      TEXT        NOT NULL,
+    delay_seconds INTEGER     NOT NULL DEFAULT 0,
+    status        TEXT        NOT NULL DEFAULT 'queued',
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    execution_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS jobs_tenant_status_idx ON jobs (tenant_id, status);
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id                SERIAL PRIMARY KEY,
+    tenant_id         TEXT        NOT NULL,
+    name              TEXT        NOT NULL,
+    key_hash          TEXT        NOT NULL UNIQUE,
+    roles             TEXT[]      NOT NULL DEFAULT '{}',
+    allowed_job_types TEXT[]      NOT NULL DEFAULT '{}',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at        TIMESTAMPTZ
+);
@@ -0,0 +1,8 @@
+DROP TABLE job_attempts;
+
+DROP INDEX jobs_status_created_at_idx;
+
+ALTER TABLE jobs
+    DROP COLUMN attempts,
+    DROP COLUMN last_error,
+    DROP COLUMN updated_at;
@@ -0,0 +1,18 @@
+ALTER TABLE jobs
+    ADD COLUMN attempts   INTEGER     NOT NULL DEFAULT 0,
+    ADD COLUMN last_error TEXT,
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX jobs_status_created_at_idx ON jobs (status, created_at DESC);
+
+CREATE TABLE job_attempts (
+    id          BIGSERIAL PRIMARY KEY,
+    job_id      INTEGER     NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    attempt     INTEGER     NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    outcome     TEXT        NOT NULL,
+    error       TEXT
+);
+
+CREATE INDEX job_attempts_job_id_idx ON job_attempts (job_id, attempt);
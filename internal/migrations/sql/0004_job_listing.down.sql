@@ -0,0 +1,8 @@
+DROP INDEX jobs_labels_idx;
+DROP INDEX jobs_tenant_priority_created_at_idx;
+DROP INDEX jobs_tenant_type_created_at_idx;
+DROP INDEX jobs_tenant_updated_at_idx;
+DROP INDEX jobs_tenant_execution_at_idx;
+DROP INDEX jobs_tenant_created_at_idx;
+
+ALTER TABLE jobs DROP COLUMN labels;
@@ -0,0 +1,10 @@
+ALTER TABLE jobs ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+
+-- Keyset pagination for GET /apis/v1/jobs: one index per sortable column,
+-- each scoped to the tenant and tie-broken on id.
+CREATE INDEX jobs_tenant_created_at_idx ON jobs (tenant_id, created_at, id);
+CREATE INDEX jobs_tenant_execution_at_idx ON jobs (tenant_id, execution_at, id);
+CREATE INDEX jobs_tenant_updated_at_idx ON jobs (tenant_id, updated_at, id);
+CREATE INDEX jobs_tenant_type_created_at_idx ON jobs (tenant_id, type, created_at, id);
+CREATE INDEX jobs_tenant_priority_created_at_idx ON jobs (tenant_id, priority, created_at, id);
+CREATE INDEX jobs_labels_idx ON jobs USING GIN (labels jsonb_path_ops);
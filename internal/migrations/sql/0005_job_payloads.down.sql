@@ -0,0 +1,10 @@
+ALTER TABLE jobs
+    ALTER COLUMN data DROP EXPRESSION,
+    ALTER COLUMN message DROP EXPRESSION;
+
+UPDATE jobs SET data = COALESCE(data, payload::text), message = COALESCE(message, '');
+
+ALTER TABLE jobs
+    ALTER COLUMN data SET NOT NULL,
+    ALTER COLUMN message SET NOT NULL,
+    DROP COLUMN payload;
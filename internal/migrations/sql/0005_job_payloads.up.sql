@@ -0,0 +1,14 @@
+-- Payloads become arbitrary JSON. data and message are kept as columns
+-- generated from the payload so the original two-field shape stays
+-- queryable.
+ALTER TABLE jobs ADD COLUMN payload JSONB NOT NULL DEFAULT '{}';
+
+UPDATE jobs SET payload = jsonb_build_object('data', data, 'message', message);
+
+ALTER TABLE jobs
+    DROP COLUMN data,
+    DROP COLUMN message;
+
+ALTER TABLE jobs
+    ADD COLUMN data    TEXT GENERATED ALWAYS AS (payload ->> 'data') STORED,
+    ADD COLUMN message TEXT GENERATED ALWAYS AS (payload ->> 'message') STORED;
@@ -0,0 +1,3 @@
+DROP INDEX jobs_message_trgm_idx;
+DROP INDEX jobs_data_trgm_idx;
+DROP INDEX jobs_payload_idx;
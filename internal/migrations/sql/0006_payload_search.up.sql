@@ -0,0 +1,9 @@
+-- Needs a role allowed to create extensions; pg_trgm ships with Postgres.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- payload.<path>=<value> filters are containment queries.
+CREATE INDEX jobs_payload_idx ON jobs USING GIN (payload jsonb_path_ops);
+
+-- data/message prefix and contains search.
+CREATE INDEX jobs_data_trgm_idx ON jobs USING GIN (data gin_trgm_ops);
+CREATE INDEX jobs_message_trgm_idx ON jobs USING GIN (message gin_trgm_ops);
@@ -0,0 +1,4 @@
+ALTER TABLE jobs
+    DROP COLUMN retries,
+    DROP COLUMN request_id,
+    DROP COLUMN trace_context;
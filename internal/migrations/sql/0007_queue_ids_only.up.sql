@@ -0,0 +1,7 @@
+-- Redis now holds only job IDs; everything the worker needs at claim time
+-- lives on the row. retries counts automatic retries within the current
+-- retry budget and is reset by a manual retry.
+ALTER TABLE jobs
+    ADD COLUMN retries       INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN request_id    TEXT,
+    ADD COLUMN trace_context JSONB;
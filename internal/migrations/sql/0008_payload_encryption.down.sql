@@ -0,0 +1,6 @@
+DROP INDEX jobs_payload_key_id_idx;
+
+ALTER TABLE jobs
+    DROP COLUMN payload_key_id,
+    DROP COLUMN payload_dek,
+    DROP COLUMN payload_ciphertext;
@@ -0,0 +1,8 @@
+-- Encrypted payloads keep payload = '{}' and store the sealed payload here,
+-- so payload filters and data/message search only match plaintext rows.
+ALTER TABLE jobs
+    ADD COLUMN payload_key_id     TEXT,
+    ADD COLUMN payload_dek        BYTEA,
+    ADD COLUMN payload_ciphertext BYTEA;
+
+CREATE INDEX jobs_payload_key_id_idx ON jobs (payload_key_id, id) WHERE payload_key_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- retry_policy is the per-job override of the job type's retry policy;
+-- NULL uses the configured policy unchanged.
+ALTER TABLE jobs ADD COLUMN retry_policy JSONB;
@@ -0,0 +1,3 @@
+-- progress holds the handler's latest report for the running attempt and is
+-- cleared when an attempt starts.
+ALTER TABLE jobs ADD COLUMN progress JSONB;
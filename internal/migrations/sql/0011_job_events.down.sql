@@ -0,0 +1,3 @@
+DROP TRIGGER jobs_record_event ON jobs;
+DROP FUNCTION record_job_event();
+DROP TABLE job_events;
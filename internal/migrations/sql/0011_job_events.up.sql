@@ -0,0 +1,44 @@
+-- Every status change of a job, for the /events stream. The trigger covers
+-- all writers (API, worker, dashboard) and notifies listeners on the
+-- job_events channel with the new event id once the change commits.
+CREATE TABLE job_events (
+    id              BIGSERIAL PRIMARY KEY,
+    job_id          INTEGER     NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    tenant_id       TEXT        NOT NULL,
+    type            TEXT        NOT NULL,
+    status          TEXT        NOT NULL,
+    previous_status TEXT,
+    attempts        INTEGER     NOT NULL,
+    last_error      TEXT,
+    labels          JSONB       NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX job_events_tenant_id_idx ON job_events (tenant_id, id);
+CREATE INDEX job_events_created_at_idx ON job_events (created_at);
+
+CREATE FUNCTION record_job_event() RETURNS trigger AS $$
+DECLARE
+    event_id BIGINT;
+    previous TEXT;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        IF NEW.status IS NOT DISTINCT FROM OLD.status THEN
+            RETURN NULL;
+        END IF;
+        previous := OLD.status;
+    END IF;
+
+    INSERT INTO job_events (job_id, tenant_id, type, status, previous_status, attempts, last_error, labels)
+    VALUES (NEW.id, NEW.tenant_id, NEW.type, NEW.status, previous, NEW.attempts, NEW.last_error,
+            COALESCE(NEW.labels, '{}'))
+    RETURNING id INTO event_id;
+
+    PERFORM pg_notify('job_events', event_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_record_event
+    AFTER INSERT OR UPDATE OF status ON jobs
+    FOR EACH ROW EXECUTE FUNCTION record_job_event();
@@ -0,0 +1,4 @@
+DROP TABLE callback_deliveries;
+ALTER TABLE jobs
+    DROP COLUMN callback_events,
+    DROP COLUMN callback_url;
@@ -0,0 +1,25 @@
+-- Completion callbacks. callback_events holds the events a job's producer
+-- asked for; each one that fires becomes a row in callback_deliveries, which
+-- the worker delivers with its own retries and which records the outcome.
+ALTER TABLE jobs
+    ADD COLUMN callback_url    TEXT,
+    ADD COLUMN callback_events TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE callback_deliveries (
+    id              BIGSERIAL PRIMARY KEY,
+    job_id          INTEGER     NOT NULL REFERENCES jobs (id) ON DELETE CASCADE,
+    tenant_id       TEXT        NOT NULL,
+    event           TEXT        NOT NULL,
+    url             TEXT        NOT NULL,
+    body            JSONB       NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    response_code   INTEGER,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX callback_deliveries_job_id_idx ON callback_deliveries (job_id, id);
+CREATE INDEX callback_deliveries_pending_idx ON callback_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,4 @@
+-- claim_token identifies the claim a job in progress is running under. A
+-- worker's final updates match it, so a worker whose job was released and
+-- claimed again elsewhere cannot overwrite the new claim's outcome.
+ALTER TABLE jobs ADD COLUMN claim_token TEXT;
@@ -0,0 +1,116 @@
+// Package progress persists the progress handlers report while a job runs.
+package progress
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Reporter writes one job's progress to Postgres at most once per
+// config.PROGRESS_UPDATE_INTERVAL. An update that arrives sooner is held
+// back until the interval has passed, unless it starts a new stage or reports
+// completion. Writes are best-effort: failures are only logged.
+type Reporter struct {
+	postgresPool *pgxpool.Pool
+	jobID        int
+	claimToken   string
+	log          *zap.SugaredLogger
+
+	mu        sync.Mutex
+	written   time.Time
+	lastStage string
+	pending   *models.JobProgress
+	// timer writes pending once the interval since the last write ends.
+	timer *time.Timer
+}
+
+// NewReporter reports progress for the claim of jobID identified by
+// claimToken; once the job is claimed again, its old reporter writes nothing.
+func NewReporter(postgresPool *pgxpool.Pool, jobID int, claimToken string, log *zap.SugaredLogger) *Reporter {
+	return &Reporter{postgresPool: postgresPool, jobID: jobID, claimToken: claimToken, log: log}
+}
+
+// Report records percent (clamped to 0-100), stage and message.
+func (r *Reporter) Report(percent int, stage, message string) {
+	update := models.JobProgress{
+		Percent:   min(max(percent, 0), 100),
+		Stage:     truncate(strings.TrimSpace(stage), config.MAX_PROGRESS_STAGE_BYTES),
+		Message:   truncate(strings.TrimSpace(message), config.MAX_PROGRESS_MESSAGE_BYTES),
+		UpdatedAt: time.Now(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wait := config.PROGRESS_UPDATE_INTERVAL - update.UpdatedAt.Sub(r.written); wait > 0 &&
+		update.Stage == r.lastStage && update.Percent < 100 {
+		r.pending = &update
+		if r.timer == nil {
+			var timer *time.Timer
+			timer = time.AfterFunc(wait, func() { r.flushPending(timer) })
+			r.timer = timer
+		}
+		return
+	}
+	r.write(update)
+}
+
+// Flush writes an update held back by throttling without waiting for the
+// interval to end.
+func (r *Reporter) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending != nil {
+		r.write(*r.pending)
+	}
+}
+
+// flushPending runs when timer fires. A timer that was stopped too late to
+// prevent the call is no longer r.timer and does nothing.
+func (r *Reporter) flushPending(timer *time.Timer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != timer {
+		return
+	}
+	r.timer = nil
+	if r.pending != nil {
+		r.write(*r.pending)
+	}
+}
+
+func (r *Reporter) write(update models.JobProgress) {
+	r.written, r.lastStage, r.pending = update.UpdatedAt, update.Stage, nil
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := r.postgresPool.Exec(ctx,
+		"UPDATE jobs SET progress = $2 WHERE id = $1 AND status = $3 AND claim_token = $4",
+		r.jobID, update, models.JOB_STATUS_PROGRESS, r.claimToken)
+	if err != nil {
+		r.log.Warnf("Failed to store job progress: %v", err)
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
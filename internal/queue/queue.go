@@ -1,6 +1,8 @@
 package queue
 
 import (
+	"fmt"
+
 	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
 	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
 )
@@ -21,3 +23,15 @@ func ReturnNewQueue() *JobQueueType {
 		LowPriorityJobQueue:    lowPriorityJobQueue,
 	}
 }
+
+// Priorities lists every priority a worker polls, highest first.
+var Priorities = []models.JOB_PRIORITY{
+	models.JOB_PRIORITY_HIGH,
+	models.JOB_PRIORITY_MEDIUM,
+	models.JOB_PRIORITY_LOW,
+}
+
+// RedisKey is the sorted set holding jobs of the given priority.
+func RedisKey(priority models.JOB_PRIORITY) string {
+	return fmt.Sprintf("job_%s", priority)
+}
@@ -0,0 +1,181 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"github.com/go-redis/redis/v8"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ExceededError is returned when a submission would push a tenant past one of
+// its quotas. Reason is safe to return to the caller as-is.
+type ExceededError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *ExceededError) Error() string {
+	return e.Reason
+}
+
+// submissionCounterTTL keeps a window's counter a little past the window so
+// it is still there for submissions that straddle its end.
+const submissionCounterTTL = 2 * time.Minute
+
+// admitScript counts a submission in its window only if the window still has
+// room, so rejected submissions do not use up the next caller's allowance.
+// It returns 1 when the submission is admitted and 0 when it is not.
+var admitScript = redis.NewScript(`
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call('INCR', KEYS[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// submissionWindow returns the counter key for the minute containing now and
+// how long until that minute ends and the counter starts over.
+func submissionWindow(tenantID string, now time.Time) (key string, retryAfter time.Duration) {
+	window := now.Unix() / 60
+	return fmt.Sprintf("quota:%s:submissions:%d", tenantID, window), time.Unix((window+1)*60, 0).Sub(now)
+}
+
+type Enforcer struct {
+	Settings     *config.Live
+	RedisClient  *redis.Client
+	PostgresPool *pgxpool.Pool
+}
+
+func NewEnforcer(settings *config.Live, redisClient *redis.Client, postgresPool *pgxpool.Pool) *Enforcer {
+	return &Enforcer{Settings: settings, RedisClient: redisClient, PostgresPool: postgresPool}
+}
+
+// queuedLockClass namespaces the per-tenant advisory locks that serialise
+// max_queued_jobs checks, keeping them apart from other advisory locks.
+const queuedLockClass = 7_231_026
+
+// releaseScript gives back a submission counted by admitScript. A window
+// that has already expired is left alone rather than recreated without a
+// TTL.
+var releaseScript = redis.NewScript(`
+if tonumber(redis.call('GET', KEYS[1]) or '0') > 0 then
+	redis.call('DECR', KEYS[1])
+end
+return 0
+`)
+
+// Reservation is a submission admitted by Reserve. The job must be inserted
+// through QueryRow and the reservation then committed, or cancelled if the
+// submission fails.
+type Reservation struct {
+	enforcer  *Enforcer
+	tx        pgx.Tx
+	windowKey string
+}
+
+// Reserve validates a new job against the tenant's quotas. The cheap payload
+// check runs first so oversized requests never touch Redis or Postgres.
+// With max_queued_jobs set, the count runs in a transaction holding a
+// per-tenant lock that the job's insert shares, so concurrent submissions
+// cannot all pass the same count. A non-nil error that is not an
+// *ExceededError means a quota could not be evaluated.
+func (e *Enforcer) Reserve(ctx context.Context, tenantID string, payloadBytes int) (*Reservation, error) {
+	quota := e.Settings.Get().QuotaForTenant(tenantID)
+
+	if quota.MaxPayloadBytes > 0 && payloadBytes > quota.MaxPayloadBytes {
+		return nil, &ExceededError{
+			Reason: fmt.Sprintf("payload size %d bytes exceeds limit of %d bytes", payloadBytes, quota.MaxPayloadBytes),
+		}
+	}
+
+	r := &Reservation{enforcer: e}
+	if quota.SubmissionsPerMinute > 0 {
+		now := time.Now()
+		key, retryAfter := submissionWindow(tenantID, now)
+		admitted, err := admitScript.Run(ctx, e.RedisClient, []string{key},
+			quota.SubmissionsPerMinute, int(submissionCounterTTL.Seconds()),
+		).Int()
+		if err != nil {
+			return nil, fmt.Errorf("increment submission counter: %w", err)
+		}
+		if admitted == 0 {
+			return nil, &ExceededError{
+				Reason:     fmt.Sprintf("submission rate limit of %d per minute exceeded", quota.SubmissionsPerMinute),
+				RetryAfter: retryAfter,
+			}
+		}
+		r.windowKey = key
+	}
+
+	if quota.MaxQueuedJobs > 0 {
+		if err := r.countQueued(ctx, tenantID, quota.MaxQueuedJobs); err != nil {
+			r.Cancel(ctx)
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Reservation) countQueued(ctx context.Context, tenantID string, limit int) error {
+	tx, err := r.enforcer.PostgresPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("count queued jobs: %w", err)
+	}
+	r.tx = tx
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, hashtext($2))", queuedLockClass, tenantID); err != nil {
+		return fmt.Errorf("lock tenant queue count: %w", err)
+	}
+	var queued int
+	err = tx.QueryRow(ctx,
+		"SELECT count(*) FROM jobs WHERE tenant_id = $1 AND status = $2",
+		tenantID, models.JOB_STATUS_QUEUED,
+	).Scan(&queued)
+	if err != nil {
+		return fmt.Errorf("count queued jobs: %w", err)
+	}
+	if queued >= limit {
+		return &ExceededError{
+			Reason: fmt.Sprintf("tenant has %d queued jobs, limit is %d", queued, limit),
+		}
+	}
+	return nil
+}
+
+// QueryRow runs the job's insert inside the reservation's transaction, if
+// it has one.
+func (r *Reservation) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if r.tx != nil {
+		return r.tx.QueryRow(ctx, sql, args...)
+	}
+	return r.enforcer.PostgresPool.QueryRow(ctx, sql, args...)
+}
+
+// Commit makes the insert visible and releases the tenant's lock.
+func (r *Reservation) Commit(ctx context.Context) error {
+	if r.tx == nil {
+		return nil
+	}
+	return r.tx.Commit(ctx)
+}
+
+// Cancel rolls back an uncommitted insert and gives back the submission's
+// rate limit slot. It is safe to call after Commit, when the job failed
+// after being stored; only the slot is given back then.
+func (r *Reservation) Cancel(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if r.tx != nil {
+		r.tx.Rollback(ctx)
+	}
+	if r.windowKey != "" {
+		releaseScript.Run(ctx, r.enforcer.RedisClient, []string{r.windowKey})
+		r.windowKey = ""
+	}
+}
@@ -0,0 +1,88 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+)
+
+func TestSubmissionWindow(t *testing.T) {
+	start := time.Unix(1_700_000_040, 0) // the first second of a minute
+	tests := []struct {
+		name           string
+		now            time.Time
+		wantKey        string
+		wantRetryAfter time.Duration
+	}{
+		{"window start", start, "quota:tenant-a:submissions:28333334", time.Minute},
+		{"mid window", start.Add(45 * time.Second), "quota:tenant-a:submissions:28333334", 15 * time.Second},
+		{"last instant", start.Add(time.Minute - time.Millisecond), "quota:tenant-a:submissions:28333334", time.Millisecond},
+		{"counter resets", start.Add(time.Minute), "quota:tenant-a:submissions:28333335", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, retryAfter := submissionWindow("tenant-a", tt.now)
+			if key != tt.wantKey {
+				t.Errorf("key = %q, want %q", key, tt.wantKey)
+			}
+			if retryAfter != tt.wantRetryAfter {
+				t.Errorf("retryAfter = %v, want %v", retryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestSubmissionWindowIsPerTenant(t *testing.T) {
+	now := time.Now()
+	a, _ := submissionWindow("tenant-a", now)
+	b, _ := submissionWindow("tenant-b", now)
+	if a == b {
+		t.Errorf("tenants share counter key %q", a)
+	}
+}
+
+// Payload size is checked before either store is touched, so these cases
+// run without Redis or Postgres.
+func TestReservePayloadSize(t *testing.T) {
+	cfg := config.Config{
+		DefaultTenantQuota: config.TenantQuota{MaxPayloadBytes: 1024},
+		TenantQuotas: map[string]config.TenantQuota{
+			"small": {MaxPayloadBytes: 10},
+		},
+	}
+	enforcer := NewEnforcer(config.NewLive(cfg), nil, nil)
+
+	tests := []struct {
+		name     string
+		tenantID string
+		bytes    int
+		exceeded bool
+	}{
+		{"within default limit", "tenant-a", 1024, false},
+		{"over default limit", "tenant-a", 1025, true},
+		{"within tenant override", "small", 10, false},
+		{"over tenant override", "small", 11, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation, err := enforcer.Reserve(context.Background(), tt.tenantID, tt.bytes)
+			if !tt.exceeded {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				reservation.Cancel(context.Background())
+				return
+			}
+			var exceeded *ExceededError
+			if !errors.As(err, &exceeded) {
+				t.Fatalf("err = %v, want *ExceededError", err)
+			}
+			if exceeded.RetryAfter != 0 {
+				t.Errorf("RetryAfter = %v, want 0 for a payload that will never fit", exceeded.RetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+// Package retry decides what happens to a job after a failed attempt. A
+// policy comes from the global defaults, the job type's entry in the
+// configuration and the job's own override, in increasing precedence.
+// Handlers steer the decision by wrapping their errors with Fatal, After or
+// Classify.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+// Error classes assigned without help from the handler. Handlers may use
+// any other name through Classify.
+const (
+	CLASS_TIMEOUT = "timeout"
+	CLASS_NETWORK = "network"
+	CLASS_PAYLOAD = "payload"
+	CLASS_UNKNOWN = "unknown"
+)
+
+// Error carries a handler's instructions for a failed attempt.
+type Error struct {
+	Err   error
+	Class string
+	// Fatal stops any further attempt regardless of the policy.
+	Fatal bool
+	// After replaces the policy's delay for the next attempt.
+	After time.Duration
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+func (e *Error) Unwrap() error { return e.Err }
+
+// Fatal marks err as not worth retrying.
+func Fatal(err error) error {
+	return &Error{Err: err, Fatal: true}
+}
+
+// After asks for the next attempt to run after delay. The policy's attempt
+// limit and error classes still apply, and delay is capped at its
+// max_delay_sec.
+func After(delay time.Duration, err error) error {
+	return &Error{Err: err, After: delay}
+}
+
+// Classify assigns err to class so policies can list it in retry_on or
+// fatal_on.
+func Classify(class string, err error) error {
+	return &Error{Err: err, Class: class}
+}
+
+// IsFatal reports whether a handler marked err with Fatal.
+func IsFatal(err error) bool {
+	var retryErr *Error
+	return errors.As(err, &retryErr) && retryErr.Fatal
+}
+
+// ClassOf returns the class of err: the one given through Classify, or
+// one derived from well-known errors, or CLASS_UNKNOWN.
+func ClassOf(err error) string {
+	var retryErr *Error
+	if errors.As(err, &retryErr) && retryErr.Class != "" {
+		return retryErr.Class
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return CLASS_TIMEOUT
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return CLASS_TIMEOUT
+	case errors.As(err, &netErr):
+		return CLASS_NETWORK
+	default:
+		return CLASS_UNKNOWN
+	}
+}
+
+// Override returns base with every non-zero field of override applied.
+func Override(base, override models.RetryPolicy) models.RetryPolicy {
+	if override.MaxAttempts != 0 {
+		base.MaxAttempts = override.MaxAttempts
+	}
+	if override.Strategy != "" {
+		base.Strategy = override.Strategy
+	}
+	if override.BaseDelaySec != 0 {
+		base.BaseDelaySec = override.BaseDelaySec
+	}
+	if override.MaxDelaySec != 0 {
+		base.MaxDelaySec = override.MaxDelaySec
+	}
+	if override.Jitter != nil {
+		base.Jitter = override.Jitter
+	}
+	if override.RetryOn != nil {
+		base.RetryOn = override.RetryOn
+	}
+	if override.FatalOn != nil {
+		base.FatalOn = override.FatalOn
+	}
+	return base
+}
+
+// Validate reports the first invalid field of policy. Zero values are valid
+// since they inherit.
+func Validate(policy models.RetryPolicy) error {
+	switch policy.Strategy {
+	case "", models.RETRY_STRATEGY_EXPONENTIAL, models.RETRY_STRATEGY_LINEAR, models.RETRY_STRATEGY_FIXED:
+	default:
+		return fmt.Errorf("strategy must be %s, %s or %s", models.RETRY_STRATEGY_EXPONENTIAL,
+			models.RETRY_STRATEGY_LINEAR, models.RETRY_STRATEGY_FIXED)
+	}
+	switch {
+	case policy.MaxAttempts < 0:
+		return errors.New("max_attempts must not be negative")
+	case policy.BaseDelaySec < 0:
+		return errors.New("base_delay_sec must not be negative")
+	case policy.MaxDelaySec < 0:
+		return errors.New("max_delay_sec must not be negative")
+	case policy.Jitter != nil && (*policy.Jitter < 0 || *policy.Jitter > 1):
+		return errors.New("jitter must be between 0 and 1")
+	case slices.Contains(policy.RetryOn, "") || slices.Contains(policy.FatalOn, ""):
+		return errors.New("error classes must not be empty")
+	}
+	return nil
+}
+
+// CheckBounds reports the first field of a submission's override that asks
+// for more than maxAttempts attempts or for delays shorter than minDelaySec.
+func CheckBounds(override models.RetryPolicy, maxAttempts, minDelaySec int) error {
+	switch {
+	case override.MaxAttempts > maxAttempts:
+		return fmt.Errorf("max_attempts must be at most %d", maxAttempts)
+	case override.BaseDelaySec != 0 && override.BaseDelaySec < minDelaySec:
+		return fmt.Errorf("base_delay_sec must be at least %d", minDelaySec)
+	case override.MaxDelaySec != 0 && override.MaxDelaySec < minDelaySec:
+		return fmt.Errorf("max_delay_sec must be at least %d", minDelaySec)
+	}
+	return nil
+}
+
+// Bound clamps a submission's override to the limits CheckBounds enforces.
+// Jobs are checked when they are submitted, but the limits can be lowered
+// while they wait.
+func Bound(override models.RetryPolicy, maxAttempts, minDelaySec int) models.RetryPolicy {
+	override.MaxAttempts = min(override.MaxAttempts, maxAttempts)
+	if override.BaseDelaySec != 0 {
+		override.BaseDelaySec = max(override.BaseDelaySec, minDelaySec)
+	}
+	if override.MaxDelaySec != 0 {
+		override.MaxDelaySec = max(override.MaxDelaySec, minDelaySec)
+	}
+	return override
+}
+
+// Decision is the outcome of a failed attempt.
+type Decision struct {
+	Retry bool
+	Delay time.Duration
+	// Reason explains a decision not to retry.
+	Reason string
+}
+
+// Decide applies policy to err, the failure of the attempt that followed
+// retries earlier retries.
+func Decide(policy models.RetryPolicy, retries int, err error) Decision {
+	var retryErr *Error
+	errors.As(err, &retryErr)
+	class := ClassOf(err)
+
+	switch {
+	case IsFatal(err):
+		return Decision{Reason: "handler marked the error fatal"}
+	case slices.Contains(policy.FatalOn, class):
+		return Decision{Reason: fmt.Sprintf("error class %q is fatal", class)}
+	case len(policy.RetryOn) > 0 && !slices.Contains(policy.RetryOn, class):
+		return Decision{Reason: fmt.Sprintf("error class %q is not retryable", class)}
+	case retries+1 >= policy.MaxAttempts:
+		return Decision{Reason: fmt.Sprintf("%d of %d attempts used", retries+1, policy.MaxAttempts)}
+	}
+
+	if retryErr != nil && retryErr.After > 0 {
+		delay := retryErr.After
+		if limit := saturatingMul(time.Second, int64(policy.MaxDelaySec)); limit > 0 && delay > limit {
+			delay = limit
+		}
+		return Decision{Retry: true, Delay: delay}
+	}
+	return Decision{Retry: true, Delay: Delay(policy, retries+1)}
+}
+
+// Delay is the wait before retry number n, counting from 1. Delays too long
+// for a time.Duration saturate instead of overflowing.
+func Delay(policy models.RetryPolicy, n int) time.Duration {
+	base := saturatingMul(time.Second, int64(policy.BaseDelaySec))
+	var delay time.Duration
+	switch policy.Strategy {
+	case models.RETRY_STRATEGY_FIXED:
+		delay = base
+	case models.RETRY_STRATEGY_LINEAR:
+		delay = saturatingMul(base, int64(n))
+	default:
+		delay = saturatingMul(base, 1<<min(max(n, 0), 62))
+	}
+
+	limit := saturatingMul(time.Second, int64(policy.MaxDelaySec))
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	if policy.Jitter != nil && *policy.Jitter > 0 {
+		// Subtracting the random part keeps the result within [0, delay]
+		// even when delay is the largest Duration.
+		spread := float64(delay) * *policy.Jitter
+		delay -= time.Duration(rand.Float64() * spread)
+	}
+	return delay
+}
+
+// saturatingMul returns d * factor for non-negative operands, or the
+// largest Duration if the product does not fit.
+func saturatingMul(d time.Duration, factor int64) time.Duration {
+	if d <= 0 || factor <= 0 {
+		return 0
+	}
+	if factor > math.MaxInt64/int64(d) {
+		return math.MaxInt64
+	}
+	return d * time.Duration(factor)
+}
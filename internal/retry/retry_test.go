@@ -0,0 +1,145 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+)
+
+func TestDecide(t *testing.T) {
+	errBoom := errors.New("boom")
+	policy := models.RetryPolicy{
+		MaxAttempts:  3,
+		Strategy:     models.RETRY_STRATEGY_FIXED,
+		BaseDelaySec: 10,
+		MaxDelaySec:  60,
+	}
+	withClasses := func(retryOn, fatalOn []string) models.RetryPolicy {
+		p := policy
+		p.RetryOn, p.FatalOn = retryOn, fatalOn
+		return p
+	}
+
+	tests := []struct {
+		name      string
+		policy    models.RetryPolicy
+		retries   int
+		err       error
+		wantRetry bool
+		wantDelay time.Duration
+	}{
+		{name: "first failure", policy: policy, retries: 0, err: errBoom, wantRetry: true, wantDelay: 10 * time.Second},
+		{name: "second failure", policy: policy, retries: 1, err: errBoom, wantRetry: true, wantDelay: 10 * time.Second},
+		{name: "attempts used", policy: policy, retries: 2, err: errBoom},
+		{name: "past the limit", policy: policy, retries: 7, err: errBoom},
+		{name: "single attempt", policy: models.RetryPolicy{MaxAttempts: 1}, retries: 0, err: errBoom},
+		{name: "fatal", policy: policy, retries: 0, err: Fatal(errBoom)},
+		{name: "wrapped fatal", policy: policy, retries: 0, err: fmt.Errorf("send: %w", Fatal(errBoom))},
+		{name: "fatal_on class", policy: withClasses(nil, []string{CLASS_PAYLOAD}), err: Classify(CLASS_PAYLOAD, errBoom)},
+		{name: "fatal_on other class", policy: withClasses(nil, []string{CLASS_PAYLOAD}), err: errBoom,
+			wantRetry: true, wantDelay: 10 * time.Second},
+		{name: "retry_on class", policy: withClasses([]string{CLASS_TIMEOUT}, nil), err: context.DeadlineExceeded,
+			wantRetry: true, wantDelay: 10 * time.Second},
+		{name: "not in retry_on", policy: withClasses([]string{CLASS_TIMEOUT}, nil), err: errBoom},
+		{name: "fatal_on beats retry_on", policy: withClasses([]string{"quota"}, []string{"quota"}),
+			err: Classify("quota", errBoom)},
+		{name: "after", policy: policy, err: After(30*time.Second, errBoom), wantRetry: true, wantDelay: 30 * time.Second},
+		{name: "after clamped", policy: policy, err: After(time.Hour, errBoom), wantRetry: true, wantDelay: time.Minute},
+		{name: "after without limit", policy: models.RetryPolicy{MaxAttempts: 3}, err: After(time.Hour, errBoom),
+			wantRetry: true, wantDelay: time.Hour},
+		{name: "after respects attempts", policy: policy, retries: 2, err: After(time.Second, errBoom)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decide(tt.policy, tt.retries, tt.err)
+			if got.Retry != tt.wantRetry || got.Delay != tt.wantDelay {
+				t.Fatalf("Decide = %+v, want retry %v after %v", got, tt.wantRetry, tt.wantDelay)
+			}
+			if !got.Retry && got.Reason == "" {
+				t.Error("a decision not to retry has no reason")
+			}
+		})
+	}
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy models.RetryPolicy
+		n      int
+		want   time.Duration
+	}{
+		{"fixed", models.RetryPolicy{Strategy: models.RETRY_STRATEGY_FIXED, BaseDelaySec: 5}, 4, 5 * time.Second},
+		{"linear", models.RetryPolicy{Strategy: models.RETRY_STRATEGY_LINEAR, BaseDelaySec: 5}, 4, 20 * time.Second},
+		{"exponential", models.RetryPolicy{Strategy: models.RETRY_STRATEGY_EXPONENTIAL, BaseDelaySec: 5}, 3, 40 * time.Second},
+		{"capped", models.RetryPolicy{BaseDelaySec: 5, MaxDelaySec: 30}, 3, 30 * time.Second},
+		{"many retries capped", models.RetryPolicy{BaseDelaySec: 5, MaxDelaySec: 3600}, 1000, time.Hour},
+		{"overflow saturates", models.RetryPolicy{MaxDelaySec: 0, BaseDelaySec: 10}, 40, math.MaxInt64},
+		{"overflow capped", models.RetryPolicy{BaseDelaySec: 10, MaxDelaySec: 3600}, 40, time.Hour},
+		{"linear overflow saturates", models.RetryPolicy{Strategy: models.RETRY_STRATEGY_LINEAR, BaseDelaySec: math.MaxInt32}, math.MaxInt32, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Delay(tt.policy, tt.n); got != tt.want {
+				t.Errorf("Delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Jitter on a saturated delay must stay within range instead of wrapping.
+func TestDelayJitterSaturated(t *testing.T) {
+	jitter := 1.0
+	policy := models.RetryPolicy{BaseDelaySec: 10, Jitter: &jitter}
+	for i := 0; i < 100; i++ {
+		if got := Delay(policy, 40); got < 0 {
+			t.Fatalf("Delay = %v, want a non-negative delay", got)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	const maxAttempts, minDelaySec = 10, 2
+	tests := []struct {
+		name     string
+		override models.RetryPolicy
+		wantErr  bool
+		want     models.RetryPolicy
+	}{
+		{name: "inherits", override: models.RetryPolicy{}, want: models.RetryPolicy{}},
+		{name: "within limits", override: models.RetryPolicy{MaxAttempts: 10, BaseDelaySec: 2, MaxDelaySec: 60},
+			want: models.RetryPolicy{MaxAttempts: 10, BaseDelaySec: 2, MaxDelaySec: 60}},
+		{name: "too many attempts", override: models.RetryPolicy{MaxAttempts: 1_000_000}, wantErr: true,
+			want: models.RetryPolicy{MaxAttempts: 10}},
+		{name: "base delay too short", override: models.RetryPolicy{BaseDelaySec: 1}, wantErr: true,
+			want: models.RetryPolicy{BaseDelaySec: 2}},
+		{name: "max delay too short", override: models.RetryPolicy{MaxDelaySec: 1}, wantErr: true,
+			want: models.RetryPolicy{MaxDelaySec: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckBounds(tt.override, maxAttempts, minDelaySec); (err != nil) != tt.wantErr {
+				t.Errorf("CheckBounds = %v, want error %v", err, tt.wantErr)
+			}
+			if got := Bound(tt.override, maxAttempts, minDelaySec); got.MaxAttempts != tt.want.MaxAttempts ||
+				got.BaseDelaySec != tt.want.BaseDelaySec || got.MaxDelaySec != tt.want.MaxDelaySec {
+				t.Errorf("Bound = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	jitter := 0.5
+	policy := models.RetryPolicy{Strategy: models.RETRY_STRATEGY_FIXED, BaseDelaySec: 10, Jitter: &jitter}
+	for i := 0; i < 100; i++ {
+		if got := Delay(policy, 1); got < 5*time.Second || got > 10*time.Second {
+			t.Fatalf("Delay = %v, want between 5s and 10s", got)
+		}
+	}
+}
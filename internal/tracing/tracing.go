@@ -0,0 +1,135 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/EightCubed/Distributed-Job-Queue-system/internal/config"
+	"github.com/EightCubed/Distributed-Job-Queue-system/pkg/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/EightCubed/Distributed-Job-Queue-system"
+
+type TRACE_EXPORTER string
+
+const (
+	TRACE_EXPORTER_NONE   TRACE_EXPORTER = "none"
+	TRACE_EXPORTER_STDOUT TRACE_EXPORTER = "stdout"
+	TRACE_EXPORTER_FILE   TRACE_EXPORTER = "file"
+	TRACE_EXPORTER_OTLP   TRACE_EXPORTER = "otlp"
+)
+
+// Setup installs the global tracer provider and W3C trace-context
+// propagator. The returned function flushes pending spans and must be called
+// before the process exits. With TRACE_EXPORTER_NONE spans are still created,
+// so trace context keeps flowing to the worker, but nothing is exported.
+func Setup(ctx context.Context, serviceName string, cfg config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("build trace resource: %w", err)
+	}
+
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	var closer io.Closer
+
+	switch TRACE_EXPORTER(cfg.TraceExporter) {
+	case "", TRACE_EXPORTER_NONE:
+	case TRACE_EXPORTER_STDOUT:
+		exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("create stdout exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case TRACE_EXPORTER_FILE:
+		file, err := os.OpenFile(cfg.TraceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("create file exporter: %w", err)
+		}
+		closer = file
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case TRACE_EXPORTER_OTLP:
+		// Endpoint, headers and TLS come from the standard
+		// OTEL_EXPORTER_OTLP_* environment variables.
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create otlp exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.TraceExporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			closer.Close()
+		}
+		return err
+	}, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Inject serialises the span context in ctx so it can travel inside a
+// queued job.
+func Inject(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// LinkFromJob returns a link to the span that submitted job, if the job
+// carries trace context.
+func LinkFromJob(job models.RedisJobType) (trace.Link, bool) {
+	if len(job.TraceContext) == 0 {
+		return trace.Link{}, false
+	}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(job.TraceContext))
+	link := trace.LinkFromContext(ctx)
+	return link, link.SpanContext.IsValid()
+}
+
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// EndSpan records err on span, if non-nil, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		RecordError(span, err)
+	}
+	span.End()
+}
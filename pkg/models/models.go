@@ -1,18 +1,70 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type Job struct {
-	ID          int       `json:"id"`
-	Type        string    `json:"type"`
-	Data        string    `json:"data"`
-	Message     string    `json:"message"`
-	Priority    string    `json:"priority"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	ExecutionAt time.Time `json:"execution_at"`
+	ID          int               `json:"id"`
+	TenantID    string            `json:"tenant_id"`
+	Type        string            `json:"type"`
+	Payload     json.RawMessage   `json:"payload"`
+	Data        string            `json:"data,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Priority    string            `json:"priority"`
+	Status      string            `json:"status"`
+	Attempts    int               `json:"attempts"`
+	LastError   string            `json:"last_error,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ExecutionAt time.Time         `json:"execution_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	// RetryPolicy is the per-job override given at submission, if any.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+	// Progress is the last update the handler reported during the current
+	// attempt.
+	Progress *JobProgress `json:"progress,omitempty"`
+	// CallbackURL receives a signed notification for each of CallbackEvents.
+	CallbackURL    string   `json:"callback_url,omitempty"`
+	CallbackEvents []string `json:"callback_events,omitempty"`
+	// PayloadRedacted is set when Payload, Data and Message were withheld
+	// from the caller.
+	PayloadRedacted bool `json:"payload_redacted,omitempty"`
+	// Encrypted is the stored ciphertext of an encrypted payload. Payload is
+	// empty until it is decrypted.
+	Encrypted *EncryptedPayload `json:"-"`
+}
+
+// JobProgress is a handler's report on a running job. UpdatedAt tells a
+// slow job, which keeps reporting, from a stuck one.
+type JobProgress struct {
+	Percent   int       `json:"percent"`
+	Stage     string    `json:"stage,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProgressReporter receives progress updates from a job handler.
+type ProgressReporter interface {
+	Report(percent int, stage, message string)
+}
+
+// EncryptedPayload is a payload sealed with a per-job data key. WrappedKey
+// is that data key encrypted under the master key KeyID.
+type EncryptedPayload struct {
+	KeyID      string
+	WrappedKey []byte
+	Ciphertext []byte
+}
+
+// JobAttempt is one execution of a job by a worker.
+type JobAttempt struct {
+	Attempt    int       `json:"attempt"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
 }
 
 type JOB_STATUS string
@@ -23,6 +75,10 @@ const (
 	JOB_STATUS_FAILED    JOB_STATUS = "failed"
 	JOB_STATUS_PROGRESS  JOB_STATUS = "progress"
 	JOB_STATUS_COMPLETED JOB_STATUS = "completed"
+	// JOB_STATUS_DEAD_LETTER marks jobs that exhausted their retries. They
+	// stay in Postgres until an operator retries or replays them.
+	JOB_STATUS_DEAD_LETTER JOB_STATUS = "dead_letter"
+	JOB_STATUS_CANCELLED   JOB_STATUS = "cancelled"
 )
 
 type JOB_PRIORITY string
@@ -42,21 +98,83 @@ const (
 )
 
 type JobBody struct {
-	Type     JOB_TYPE     `json:"type"`
-	Payload  PayloadType  `json:"payload"`
-	Priority JOB_PRIORITY `json:"priority"`
-	Delay    int          `json:"delay"`
+	Type JOB_TYPE `json:"type"`
+	// Payload is any JSON value accepted by the job type's schema.
+	Payload  json.RawMessage   `json:"payload"`
+	Priority JOB_PRIORITY      `json:"priority"`
+	Delay    int               `json:"delay"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Retry overrides the job type's retry policy field by field.
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// CallbackURL is notified of CallbackEvents, or of every event when
+	// CallbackEvents is empty.
+	CallbackURL    string           `json:"callback_url,omitempty"`
+	CallbackEvents []CALLBACK_EVENT `json:"callback_events,omitempty"`
+}
+
+type CALLBACK_EVENT string
+
+const (
+	CALLBACK_EVENT_COMPLETED CALLBACK_EVENT = "completed"
+	// CALLBACK_EVENT_FAILED is sent when the worker marks a job failed
+	// without dead-lettering it. Attempts that will be retried send nothing;
+	// every callback event is a terminal outcome.
+	CALLBACK_EVENT_FAILED        CALLBACK_EVENT = "failed"
+	CALLBACK_EVENT_DEAD_LETTERED CALLBACK_EVENT = "dead_lettered"
+)
+
+type RETRY_STRATEGY string
+
+const (
+	RETRY_STRATEGY_EXPONENTIAL RETRY_STRATEGY = "exponential"
+	RETRY_STRATEGY_LINEAR      RETRY_STRATEGY = "linear"
+	RETRY_STRATEGY_FIXED       RETRY_STRATEGY = "fixed"
+)
+
+// RetryPolicy decides whether and when a failed job runs again. Zero fields
+// inherit from the policy it overrides; see internal/retry.
+type RetryPolicy struct {
+	// MaxAttempts counts the first run, so 1 disables retries.
+	MaxAttempts  int            `json:"max_attempts,omitempty" yaml:"max_attempts"`
+	Strategy     RETRY_STRATEGY `json:"strategy,omitempty" yaml:"strategy"`
+	BaseDelaySec int            `json:"base_delay_sec,omitempty" yaml:"base_delay_sec"`
+	MaxDelaySec  int            `json:"max_delay_sec,omitempty" yaml:"max_delay_sec"`
+	// Jitter is the fraction of each delay that is randomised, from 0 to 1.
+	Jitter *float64 `json:"jitter,omitempty" yaml:"jitter"`
+	// RetryOn, when set, lists the only error classes that are retried.
+	// FatalOn lists classes that are never retried.
+	RetryOn []string `json:"retry_on,omitempty" yaml:"retry_on"`
+	FatalOn []string `json:"fatal_on,omitempty" yaml:"fatal_on"`
 }
 
+// RedisJobType is a queued job as the worker handles it. Redis queues hold
+// only JobID; the worker fills in the rest from Postgres when it claims the
+// job.
 type RedisJobType struct {
-	JobID       int          `json:"job_id`
-	Type        JOB_TYPE     `json:"type"`
-	Payload     PayloadType  `json:"payload"`
-	ExecutionAt time.Time    `json:"execution_at"`
-	Priority    JOB_PRIORITY `json:"priority"`
-	Retries     int          `json:"retries,omitempty"`
+	JobID       int             `json:"job_id"`
+	TenantID    string          `json:"tenant_id,omitempty"`
+	Type        JOB_TYPE        `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	ExecutionAt time.Time       `json:"execution_at"`
+	Priority    JOB_PRIORITY    `json:"priority"`
+	Retries     int             `json:"retries,omitempty"`
+	// RetryPolicy is the per-job override given at submission, if any.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+	// RequestID and TraceContext tie a queued job back to the API request
+	// that submitted it.
+	RequestID    string            `json:"request_id,omitempty"`
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	// Encrypted holds the payload ciphertext until the worker decrypts it.
+	Encrypted *EncryptedPayload `json:"-"`
+	// ClaimToken is set by the worker that claimed the job and guards its
+	// updates to the job's row.
+	ClaimToken string `json:"-"`
+	// Progress is set by the worker for the duration of an execution.
+	Progress ProgressReporter `json:"-"`
 }
 
+// PayloadType is the original two-field payload shape. Every built-in job
+// type still accepts it.
 type PayloadType struct {
 	Data    string `json:"data"`
 	Message string `json:"message"`
@@ -1,12 +1,31 @@
+// create_jobs is an end-to-end load generator: it submits jobs through the
+// API at a controlled rate and concurrency, polls each job until it reaches a
+// terminal status and reports submit-to-complete latency and throughput.
+//
+//	go run ./scripts -jobs 5000 -concurrency 32 -rate 200 \
+//	    -types Email=3,Message=1 -priorities HIGH=1,MEDIUM=2,LOW=1 \
+//	    -delay uniform:0-10 -format json
+//
+// The original positional form is still accepted and maps to a priority mix:
+//
+//	go run ./scripts 100 50 10
 package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Payload struct {
@@ -21,51 +40,398 @@ type JobBody struct {
 	Delay    int     `json:"delay"`
 }
 
-func submitJob(priority string, count int) {
-	url := "http://localhost:8000/apis/v1/submit-job"
+type submitResponse struct {
+	JobID int `json:"job_id"`
+}
+
+type jobStatus struct {
+	Status string `json:"status"`
+}
+
+// Terminal statuses; anything else is still in flight.
+var terminalStatuses = map[string]bool{
+	"completed":   true,
+	"dead_letter": true,
+	"cancelled":   true,
+}
+
+type options struct {
+	Endpoint        string
+	APIKey          string
+	Token           string
+	Jobs            int
+	Duration        time.Duration
+	Concurrency     int
+	Rate            float64
+	Types           weightedMix
+	Priorities      weightedMix
+	Delay           delaySpec
+	PollInterval    time.Duration
+	PollConcurrency int
+	Timeout         time.Duration
+	NoWait          bool
+	Format          string
+	Output          string
+}
+
+// trackedJob is a submitted job awaiting a terminal status.
+type trackedJob struct {
+	ID          int
+	Type        string
+	Priority    string
+	SubmittedAt time.Time
+	DueAt       time.Time
+}
+
+type loadGenerator struct {
+	opts       options
+	httpClient *http.Client
+	report     *report
+
+	mu      sync.Mutex
+	pending map[int]trackedJob
+}
+
+func main() {
+	opts, err := parseOptions(os.Args[1:])
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	gen := &loadGenerator{
+		opts:       opts,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		report:     newReport(opts),
+		pending:    map[int]trackedJob{},
+	}
+	gen.run(ctx)
+
+	out := io.Writer(os.Stdout)
+	if opts.Output != "" {
+		f, err := os.Create(opts.Output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	summary := gen.report.summarize()
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(summary)
+	} else {
+		err = summary.writeText(out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseOptions(args []string) (options, error) {
+	opts := options{
+		Types:      weightedMix{{Value: "Email", Weight: 1}},
+		Priorities: weightedMix{{Value: "HIGH", Weight: 1}, {Value: "MEDIUM", Weight: 1}, {Value: "LOW", Weight: 1}},
+	}
+
+	fs := flag.NewFlagSet("create_jobs", flag.ContinueOnError)
+	fs.StringVar(&opts.Endpoint, "endpoint", envOr("JOBCTL_ENDPOINT", "http://localhost:8000"), "API endpoint")
+	fs.StringVar(&opts.APIKey, "api-key", os.Getenv("JOBCTL_API_KEY"), "API key sent as X-API-Key")
+	fs.StringVar(&opts.Token, "token", os.Getenv("JOBCTL_TOKEN"), "bearer token, used when no API key is set")
+	fs.IntVar(&opts.Jobs, "jobs", 1000, "number of jobs to submit; 0 means until -duration elapses")
+	fs.DurationVar(&opts.Duration, "duration", 0, "stop submitting after this long; 0 means no limit")
+	fs.IntVar(&opts.Concurrency, "concurrency", 8, "concurrent submitters")
+	fs.Float64Var(&opts.Rate, "rate", 0, "target submissions per second across all submitters; 0 means unthrottled")
+	fs.Var(&opts.Types, "types", "job type mix as Type=weight,... (default Email=1)")
+	fs.Var(&opts.Priorities, "priorities", "priority mix as PRIORITY=weight,... (default HIGH=1,MEDIUM=1,LOW=1)")
+	fs.Var(&opts.Delay, "delay", "delay distribution: 0, fixed:S, uniform:MIN-MAX or exp:MEAN (seconds)")
+	fs.DurationVar(&opts.PollInterval, "poll-interval", 500*time.Millisecond, "how often to poll in-flight jobs; bounds latency resolution")
+	fs.IntVar(&opts.PollConcurrency, "poll-concurrency", 16, "concurrent status requests per poll round")
+	fs.DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "give up on jobs not finished this long after their due time")
+	fs.BoolVar(&opts.NoWait, "no-wait", false, "only measure submission; do not poll for completion")
+	fs.StringVar(&opts.Format, "format", "text", "report format: text or json")
+	fs.StringVar(&opts.Output, "out", "", "write the report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if fs.NArg() == 3 {
+		mix := weightedMix{}
+		total := 0
+		for i, priority := range []string{"HIGH", "MEDIUM", "LOW"} {
+			count, err := strconv.Atoi(fs.Arg(i))
+			if err != nil || count < 0 {
+				return opts, fmt.Errorf("invalid %s job count %q", priority, fs.Arg(i))
+			}
+			if count > 0 {
+				mix = append(mix, weightedValue{Value: priority, Weight: count})
+			}
+			total += count
+		}
+		if total == 0 {
+			return opts, fmt.Errorf("nothing to submit")
+		}
+		opts.Priorities, opts.Jobs = mix, total
+	} else if fs.NArg() != 0 {
+		return opts, fmt.Errorf("unexpected arguments %v", fs.Args())
+	}
+
+	switch {
+	case opts.Jobs <= 0 && opts.Duration <= 0:
+		return opts, fmt.Errorf("-jobs or -duration must be set")
+	case opts.Concurrency <= 0 || opts.PollConcurrency <= 0:
+		return opts, fmt.Errorf("-concurrency and -poll-concurrency must be positive")
+	case opts.PollInterval <= 0:
+		return opts, fmt.Errorf("-poll-interval must be positive")
+	case opts.Format != "text" && opts.Format != "json":
+		return opts, fmt.Errorf("unknown -format %q", opts.Format)
+	}
+	return opts, nil
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// run submits the configured load, then waits for every submitted job to
+// finish or time out.
+func (gen *loadGenerator) run(ctx context.Context) {
+	submitCtx := ctx
+	if gen.opts.Duration > 0 {
+		var cancel context.CancelFunc
+		submitCtx, cancel = context.WithTimeout(ctx, gen.opts.Duration)
+		defer cancel()
+	}
+
+	submitDone := make(chan struct{})
+	var pollWg sync.WaitGroup
+	if !gen.opts.NoWait {
+		pollWg.Add(1)
+		go func() {
+			defer pollWg.Done()
+			gen.pollUntilDone(ctx, submitDone)
+		}()
+	}
 
-	for i := 1; i <= count; i++ {
-		job := JobBody{
-			Type: "Email",
+	gen.report.start()
+	gen.submitAll(submitCtx)
+	gen.report.submitFinished()
+	close(submitDone)
+
+	pollWg.Wait()
+	gen.report.finish()
+}
+
+func (gen *loadGenerator) submitAll(ctx context.Context) {
+	jobs := make(chan JobBody)
+	var wg sync.WaitGroup
+	for i := 0; i < gen.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for body := range jobs {
+				gen.submit(ctx, body)
+			}
+		}()
+	}
+
+	var tick <-chan time.Time
+	if gen.opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / gen.opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+generate:
+	for i := 1; gen.opts.Jobs <= 0 || i <= gen.opts.Jobs; i++ {
+		if tick != nil {
+			select {
+			case <-ctx.Done():
+				break generate
+			case <-tick:
+			}
+		}
+		priority := gen.opts.Priorities.pick(rng)
+		body := JobBody{
+			Type: gen.opts.Types.pick(rng),
 			Payload: Payload{
 				Data:    fmt.Sprintf("Payload #%d [%s]", i, priority),
 				Message: "Queued by script",
 			},
 			Priority: priority,
+			Delay:    gen.opts.Delay.sample(rng),
+		}
+		select {
+		case <-ctx.Done():
+			break generate
+		case jobs <- body:
 		}
+	}
+	close(jobs)
+	wg.Wait()
+}
 
-		jobJSON, err := json.Marshal(job)
-		if err != nil {
-			fmt.Println("Failed to marshal JSON:", err)
+func (gen *loadGenerator) submit(ctx context.Context, body JobBody) {
+	submittedAt := time.Now()
+	var response submitResponse
+	status, err := gen.do(ctx, http.MethodPost, "/submit-job", body, &response)
+	gen.report.recordSubmit(time.Since(submittedAt), status, err)
+	if err != nil || gen.opts.NoWait {
+		return
+	}
+
+	gen.mu.Lock()
+	gen.pending[response.JobID] = trackedJob{
+		ID:          response.JobID,
+		Type:        body.Type,
+		Priority:    body.Priority,
+		SubmittedAt: submittedAt,
+		DueAt:       submittedAt.Add(time.Duration(body.Delay) * time.Second),
+	}
+	gen.mu.Unlock()
+}
+
+// pollUntilDone polls in-flight jobs every PollInterval until submission has
+// finished and nothing is pending.
+func (gen *loadGenerator) pollUntilDone(ctx context.Context, submitDone <-chan struct{}) {
+	ticker := time.NewTicker(gen.opts.PollInterval)
+	defer ticker.Stop()
+
+	submitting := true
+	for {
+		select {
+		case <-ctx.Done():
+			gen.abandonPending("interrupted")
+			return
+		case <-submitDone:
+			submitting = false
+			submitDone = nil
 			continue
+		case <-ticker.C:
 		}
 
-		resp, err := http.Post(url, "application/json", bytes.NewBuffer(jobJSON))
-		if err != nil {
-			fmt.Printf("Failed to send job #%d [%s]: %v\n", i, priority, err)
+		gen.pollOnce(ctx)
+
+		gen.mu.Lock()
+		remaining := len(gen.pending)
+		gen.mu.Unlock()
+		if !submitting && remaining == 0 {
+			return
+		}
+	}
+}
+
+func (gen *loadGenerator) pollOnce(ctx context.Context) {
+	now := time.Now()
+	gen.mu.Lock()
+	due := make([]trackedJob, 0, len(gen.pending))
+	for _, job := range gen.pending {
+		if now.Before(job.DueAt) {
+			continue
+		}
+		if gen.opts.Timeout > 0 && now.Sub(job.DueAt) > gen.opts.Timeout {
+			delete(gen.pending, job.ID)
+			gen.report.recordOutcome(job, "timeout", now)
 			continue
 		}
-		defer resp.Body.Close()
+		due = append(due, job)
+	}
+	gen.mu.Unlock()
 
-		fmt.Printf("Sent job #%d [%s], status: %s\n", i, priority, resp.Status)
+	jobs := make(chan trackedJob)
+	var wg sync.WaitGroup
+	for i := 0; i < gen.opts.PollConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				gen.check(ctx, job)
+			}
+		}()
+	}
+	for _, job := range due {
+		jobs <- job
 	}
+	close(jobs)
+	wg.Wait()
 }
 
-func main() {
-	args := os.Args[1:]
-	HIGH_PRIORITY_JOB_COUNT, err := strconv.Atoi(args[0])
+func (gen *loadGenerator) check(ctx context.Context, job trackedJob) {
+	var status jobStatus
+	code, err := gen.do(ctx, http.MethodGet, fmt.Sprintf("/job/%d", job.ID), nil, &status)
 	if err != nil {
-		panic(fmt.Errorf("passed in invalid arguments"))
+		gen.report.recordPollError(code, err)
+		return
+	}
+	if !terminalStatuses[status.Status] {
+		return
+	}
+
+	gen.mu.Lock()
+	delete(gen.pending, job.ID)
+	gen.mu.Unlock()
+	gen.report.recordOutcome(job, status.Status, time.Now())
+}
+
+func (gen *loadGenerator) abandonPending(reason string) {
+	now := time.Now()
+	gen.mu.Lock()
+	defer gen.mu.Unlock()
+	for id, job := range gen.pending {
+		delete(gen.pending, id)
+		gen.report.recordOutcome(job, reason, now)
 	}
-	MEDIUM_PRIORITY_JOB_COUNT, err := strconv.Atoi(args[1])
+}
+
+// do sends body as JSON to path under /apis/v1 and returns the HTTP status
+// alongside any transport or non-2xx error.
+func (gen *loadGenerator) do(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	url := strings.TrimRight(gen.opts.Endpoint, "/") + "/apis/v1" + path
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
 	if err != nil {
-		panic(fmt.Errorf("passed in invalid arguments"))
+		return 0, err
 	}
-	LOW_PRIORITY_JOB_COUNT, err := strconv.Atoi(args[2])
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case gen.opts.APIKey != "":
+		req.Header.Set("X-API-Key", gen.opts.APIKey)
+	case gen.opts.Token != "":
+		req.Header.Set("Authorization", "Bearer "+gen.opts.Token)
+	}
+
+	resp, err := gen.httpClient.Do(req)
 	if err != nil {
-		panic(fmt.Errorf("passed in invalid arguments"))
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, fmt.Errorf("%s", resp.Status)
 	}
-	submitJob("HIGH", HIGH_PRIORITY_JOB_COUNT)
-	submitJob("MEDIUM", MEDIUM_PRIORITY_JOB_COUNT)
-	submitJob("LOW", LOW_PRIORITY_JOB_COUNT)
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+type weightedValue struct {
+	Value  string
+	Weight int
+}
+
+// weightedMix is a flag.Value parsed from "A=3,B=1"; a bare "A" has weight 1.
+type weightedMix []weightedValue
+
+func (mix *weightedMix) String() string {
+	if mix == nil {
+		return ""
+	}
+	parts := make([]string, 0, len(*mix))
+	for _, entry := range *mix {
+		parts = append(parts, fmt.Sprintf("%s=%d", entry.Value, entry.Weight))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (mix *weightedMix) Set(value string) error {
+	parsed := weightedMix{}
+	for _, part := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), "=")
+		entry := weightedValue{Value: name, Weight: 1}
+		if found {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return fmt.Errorf("invalid weight in %q", part)
+			}
+			entry.Weight = w
+		}
+		if entry.Value == "" {
+			return fmt.Errorf("empty entry in %q", value)
+		}
+		if entry.Weight > 0 {
+			parsed = append(parsed, entry)
+		}
+	}
+	if len(parsed) == 0 {
+		return fmt.Errorf("mix %q has no positive weights", value)
+	}
+	*mix = parsed
+	return nil
+}
+
+func (mix weightedMix) pick(rng *rand.Rand) string {
+	total := 0
+	for _, entry := range mix {
+		total += entry.Weight
+	}
+	n := rng.Intn(total)
+	for _, entry := range mix {
+		if n < entry.Weight {
+			return entry.Value
+		}
+		n -= entry.Weight
+	}
+	return mix[len(mix)-1].Value
+}
+
+type DELAY_DISTRIBUTION string
+
+const (
+	DELAY_NONE        DELAY_DISTRIBUTION = "none"
+	DELAY_FIXED       DELAY_DISTRIBUTION = "fixed"
+	DELAY_UNIFORM     DELAY_DISTRIBUTION = "uniform"
+	DELAY_EXPONENTIAL DELAY_DISTRIBUTION = "exp"
+)
+
+// delaySpec is a flag.Value describing per-job delay in seconds: "0",
+// "fixed:S", "uniform:MIN-MAX" or "exp:MEAN".
+type delaySpec struct {
+	Distribution DELAY_DISTRIBUTION
+	Min, Max     int
+	Mean         float64
+}
+
+func (spec *delaySpec) String() string {
+	switch spec.Distribution {
+	case DELAY_FIXED:
+		return fmt.Sprintf("fixed:%d", spec.Min)
+	case DELAY_UNIFORM:
+		return fmt.Sprintf("uniform:%d-%d", spec.Min, spec.Max)
+	case DELAY_EXPONENTIAL:
+		return fmt.Sprintf("exp:%g", spec.Mean)
+	default:
+		return "0"
+	}
+}
+
+func (spec *delaySpec) Set(value string) error {
+	kind, args, _ := strings.Cut(value, ":")
+	var err error
+	switch DELAY_DISTRIBUTION(kind) {
+	case "0", DELAY_NONE:
+		*spec = delaySpec{Distribution: DELAY_NONE}
+	case DELAY_FIXED:
+		spec.Distribution = DELAY_FIXED
+		spec.Min, err = strconv.Atoi(args)
+		spec.Max = spec.Min
+	case DELAY_UNIFORM:
+		spec.Distribution = DELAY_UNIFORM
+		low, high, found := strings.Cut(args, "-")
+		if !found {
+			return fmt.Errorf("uniform delay needs MIN-MAX, got %q", args)
+		}
+		if spec.Min, err = strconv.Atoi(low); err == nil {
+			spec.Max, err = strconv.Atoi(high)
+		}
+		if err == nil && spec.Max < spec.Min {
+			err = fmt.Errorf("max below min")
+		}
+	case DELAY_EXPONENTIAL:
+		spec.Distribution = DELAY_EXPONENTIAL
+		spec.Mean, err = strconv.ParseFloat(args, 64)
+	default:
+		return fmt.Errorf("unknown delay distribution %q", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid delay %q: %w", value, err)
+	}
+	if spec.Min < 0 || spec.Mean < 0 {
+		return fmt.Errorf("invalid delay %q: must not be negative", value)
+	}
+	return nil
+}
+
+// sample returns a delay in whole seconds, which is what the API accepts.
+func (spec delaySpec) sample(rng *rand.Rand) int {
+	switch spec.Distribution {
+	case DELAY_FIXED:
+		return spec.Min
+	case DELAY_UNIFORM:
+		return spec.Min + rng.Intn(spec.Max-spec.Min+1)
+	case DELAY_EXPONENTIAL:
+		return int(math.Round(rng.ExpFloat64() * spec.Mean))
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// report collects measurements from submitters and pollers. All methods are
+// safe for concurrent use.
+type report struct {
+	opts options
+
+	mu              sync.Mutex
+	startedAt       time.Time
+	submitEndedAt   time.Time
+	finishedAt      time.Time
+	submitLatencies []time.Duration
+	submitErrors    map[string]int
+	pollErrors      map[string]int
+	outcomes        map[string]int
+	endToEnd        map[string][]time.Duration
+	lateness        []time.Duration
+	lastCompletedAt time.Time
+}
+
+func newReport(opts options) *report {
+	return &report{
+		opts:         opts,
+		submitErrors: map[string]int{},
+		pollErrors:   map[string]int{},
+		outcomes:     map[string]int{},
+		endToEnd:     map[string][]time.Duration{},
+	}
+}
+
+func (r *report) start() {
+	r.mu.Lock()
+	r.startedAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *report) submitFinished() {
+	r.mu.Lock()
+	r.submitEndedAt = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *report) finish() {
+	r.mu.Lock()
+	r.finishedAt = time.Now()
+	r.mu.Unlock()
+}
+
+func errorKey(status int, err error) string {
+	if status != 0 {
+		return fmt.Sprintf("%d %s", status, http.StatusText(status))
+	}
+	return "transport: " + err.Error()
+}
+
+func (r *report) recordSubmit(latency time.Duration, status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.submitErrors[errorKey(status, err)]++
+		return
+	}
+	r.submitLatencies = append(r.submitLatencies, latency)
+}
+
+func (r *report) recordPollError(status int, err error) {
+	r.mu.Lock()
+	r.pollErrors[errorKey(status, err)]++
+	r.mu.Unlock()
+}
+
+// recordOutcome counts a job's terminal status. Latencies are only kept for
+// completed jobs so failures do not skew the percentiles.
+func (r *report) recordOutcome(job trackedJob, outcome string, observedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes[outcome]++
+	if outcome != "completed" {
+		return
+	}
+	r.endToEnd[job.Priority] = append(r.endToEnd[job.Priority], observedAt.Sub(job.SubmittedAt))
+	r.lateness = append(r.lateness, observedAt.Sub(job.DueAt))
+	if observedAt.After(r.lastCompletedAt) {
+		r.lastCompletedAt = observedAt
+	}
+}
+
+// percentiles are reported in milliseconds.
+type percentiles struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+	Max   float64 `json:"max_ms"`
+}
+
+func computePercentiles(samples []time.Duration) percentiles {
+	if len(samples) == 0 {
+		return percentiles{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) float64 {
+		// Nearest-rank percentile.
+		i := int(p*float64(len(sorted))+0.999999) - 1
+		if i < 0 {
+			i = 0
+		}
+		return milliseconds(sorted[i])
+	}
+	return percentiles{
+		Count: len(sorted),
+		P50:   rank(0.50),
+		P95:   rank(0.95),
+		P99:   rank(0.99),
+		Max:   milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+type summary struct {
+	Endpoint         string                 `json:"endpoint"`
+	Concurrency      int                    `json:"concurrency"`
+	TargetRate       float64                `json:"target_rate,omitempty"`
+	Types            string                 `json:"types"`
+	Priorities       string                 `json:"priorities"`
+	Delay            string                 `json:"delay"`
+	PollIntervalMs   float64                `json:"poll_interval_ms"`
+	Submitted        int                    `json:"submitted"`
+	SubmitErrors     map[string]int         `json:"submit_errors"`
+	SubmitDuration   float64                `json:"submit_duration_seconds"`
+	SubmitThroughput float64                `json:"submit_throughput_per_second"`
+	SubmitLatency    percentiles            `json:"submit_latency"`
+	Outcomes         map[string]int         `json:"outcomes,omitempty"`
+	PollErrors       map[string]int         `json:"poll_errors,omitempty"`
+	TotalDuration    float64                `json:"total_duration_seconds"`
+	Throughput       float64                `json:"completed_throughput_per_second"`
+	EndToEnd         percentiles            `json:"submit_to_complete"`
+	EndToEndByPrio   map[string]percentiles `json:"submit_to_complete_by_priority,omitempty"`
+	DueToComplete    percentiles            `json:"due_to_complete"`
+}
+
+func (r *report) summarize() summary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := summary{
+		Endpoint:       r.opts.Endpoint,
+		Concurrency:    r.opts.Concurrency,
+		TargetRate:     r.opts.Rate,
+		Types:          r.opts.Types.String(),
+		Priorities:     r.opts.Priorities.String(),
+		Delay:          r.opts.Delay.String(),
+		PollIntervalMs: milliseconds(r.opts.PollInterval),
+		Submitted:      len(r.submitLatencies),
+		SubmitErrors:   r.submitErrors,
+		SubmitLatency:  computePercentiles(r.submitLatencies),
+		PollErrors:     r.pollErrors,
+		EndToEndByPrio: map[string]percentiles{},
+		DueToComplete:  computePercentiles(r.lateness),
+	}
+
+	submitDuration := r.submitEndedAt.Sub(r.startedAt)
+	s.SubmitDuration = submitDuration.Seconds()
+	if submitDuration > 0 {
+		s.SubmitThroughput = float64(s.Submitted) / submitDuration.Seconds()
+	}
+	s.TotalDuration = r.finishedAt.Sub(r.startedAt).Seconds()
+
+	if r.opts.NoWait {
+		return s
+	}
+	s.Outcomes = r.outcomes
+	var all []time.Duration
+	for priority, samples := range r.endToEnd {
+		s.EndToEndByPrio[priority] = computePercentiles(samples)
+		all = append(all, samples...)
+	}
+	s.EndToEnd = computePercentiles(all)
+	if window := r.lastCompletedAt.Sub(r.startedAt); len(all) > 0 && window > 0 {
+		s.Throughput = float64(len(all)) / window.Seconds()
+	}
+	return s
+}
+
+func (s summary) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Endpoint\t%s\n", s.Endpoint)
+	rate := "unthrottled"
+	if s.TargetRate > 0 {
+		rate = fmt.Sprintf("%g/s", s.TargetRate)
+	}
+	fmt.Fprintf(tw, "Load\tconcurrency=%d rate=%s types=%s priorities=%s delay=%s\n",
+		s.Concurrency, rate, s.Types, s.Priorities, s.Delay)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "Submitted\t%d in %.2fs (%.1f/s)\n", s.Submitted, s.SubmitDuration, s.SubmitThroughput)
+	writeErrors(tw, "Submit errors", s.SubmitErrors)
+	fmt.Fprintf(tw, "\t\tcount\tp50\tp95\tp99\tmax\n")
+	writePercentiles(tw, "Submit latency", "", s.SubmitLatency)
+
+	if s.Outcomes != nil {
+		fmt.Fprintln(tw)
+		writeErrors(tw, "Outcomes", s.Outcomes)
+		writeErrors(tw, "Poll errors", s.PollErrors)
+		fmt.Fprintf(tw, "Completed throughput\t%.1f/s over %.2fs\n", s.Throughput, s.TotalDuration)
+		fmt.Fprintf(tw, "\t\tcount\tp50\tp95\tp99\tmax\n")
+		writePercentiles(tw, "Submit to complete", "all", s.EndToEnd)
+		for _, priority := range sortedKeys(s.EndToEndByPrio) {
+			writePercentiles(tw, "", priority, s.EndToEndByPrio[priority])
+		}
+		writePercentiles(tw, "Due to complete", "all", s.DueToComplete)
+		fmt.Fprintf(tw, "\nLatencies are observed by polling every %gms.\n", s.PollIntervalMs)
+	}
+	return tw.Flush()
+}
+
+func writeErrors(w io.Writer, label string, counts map[string]int) {
+	if len(counts) == 0 {
+		fmt.Fprintf(w, "%s\tnone\n", label)
+		return
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", key, counts[key]))
+	}
+	fmt.Fprintf(w, "%s\t%s\n", label, strings.Join(parts, " "))
+}
+
+func writePercentiles(w io.Writer, label, group string, p percentiles) {
+	fmt.Fprintf(w, "%s\t%s\t%d\t%.1fms\t%.1fms\t%.1fms\t%.1fms\n", label, group, p.Count, p.P50, p.P95, p.P99, p.Max)
+}
+
+func sortedKeys(m map[string]percentiles) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}